@@ -17,20 +17,28 @@
 package build
 
 import (
+	"fmt"
+
 	"github.com/buildpacks/libbuildpack/v2/application"
 	"github.com/buildpacks/libbuildpack/v2/buildpack"
 	"github.com/buildpacks/libbuildpack/v2/buildpackplan"
-	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/layers"
 	"github.com/buildpacks/libbuildpack/v2/logger"
 	"github.com/buildpacks/libbuildpack/v2/platform"
 	"github.com/buildpacks/libbuildpack/v2/services"
 	"github.com/buildpacks/libbuildpack/v2/stack"
+	"github.com/buildpacks/libbuildpack/v2/version"
 )
 
 // SuccessStatusCode is the status code returned for success.
 const SuccessStatusCode = 0
 
+var (
+	readOnlyPlanAPI = version.MustParse("0.5")
+
+	buildMetadataPlatformAPI = version.MustParse("0.4")
+)
+
 // Build represents all of the components available to a buildpack at build time.
 type Build struct {
 	// Application is the application being processed by the buildpack.
@@ -67,10 +75,16 @@ func (b Build) Failure(code int) int {
 	return code
 }
 
-// Success signals a successful build by exiting with a zero status code.
+// Success signals a successful build by exiting with a zero status code.  Before buildpack API 0.5 the plans are
+// written back as the buildpack plan.  From buildpack API 0.5 the buildpack plan is read-only and is not written.
 func (b Build) Success(plans ...buildpackplan.Plan) (int, error) {
 	b.Logger.Debug("Build success. Exiting with %d.", SuccessStatusCode)
 
+	if !b.Buildpack.API.LessThan(readOnlyPlanAPI) {
+		b.Logger.Debug("Buildpack plan is read-only for buildpack API %s, not writing", b.Buildpack.API)
+		return SuccessStatusCode, nil
+	}
+
 	if err := b.Writer(buildpackplan.Plans{Entries: plans}); err != nil {
 		return -1, err
 	}
@@ -78,38 +92,111 @@ func (b Build) Success(plans ...buildpackplan.Plan) (int, error) {
 	return SuccessStatusCode, nil
 }
 
+// Result is the outcome of a successful build.
+type Result struct {
+	// Plans are written back as the buildpack plan before buildpack API 0.5.
+	Plans []buildpackplan.Plan
+
+	// Unmet are the names of buildpack plan entries that the buildpack did not satisfy.  The lifecycle passes them to
+	// subsequent buildpacks.  Requires buildpack API 0.5 or later.
+	Unmet []string
+
+	// BOM is the bill of materials contributed by the buildpack.  See WriteBOM.
+	BOM layers.BOM
+}
+
+// Complete signals a successful build with a result by exiting with a zero status code.  The unmet plan entries and BOM
+// are written to launch.toml and build.toml before the buildpack plan is handled as in Success.  An error is returned,
+// before anything is written, if an unmet plan entry is not in the buildpack plan or if unmet plan entries are not
+// available in the buildpack API version.  As with the build BOM, unmet plan entries are not written before platform
+// API 0.4, which does not support build.toml.
+func (b Build) Complete(result Result) (int, error) {
+	var unmet layers.UnmetPlanEntries
+	for _, name := range result.Unmet {
+		if !b.Plans.Contains(name) {
+			return -1, fmt.Errorf("unmet plan entry %s is not in the buildpack plan", name)
+		}
+
+		unmet = append(unmet, layers.UnmetPlanEntry{Name: name})
+	}
+
+	// Unmet plan entries are written first as they are validated against the buildpack API before writing.
+	if len(unmet) > 0 {
+		if b.Platform.API.LessThan(buildMetadataPlatformAPI) {
+			b.Logger.Debug("build.toml is not supported by platform API %s, not writing unmet plan entries", b.Platform.API)
+		} else if err := b.Layers.WriteUnmet(unmet); err != nil {
+			return -1, err
+		}
+	}
+
+	if err := b.WriteBOM(result.BOM); err != nil {
+		return -1, err
+	}
+
+	return b.Success(result.Plans...)
+}
+
+// WriteBOM writes the bill of materials contributed by the buildpack.  Entries flagged as Launch are written to the
+// launch BOM (launch.toml and launch.sbom.*) and entries flagged as Build are written to the build BOM (build.toml and
+// build.sbom.*).  The build BOM is only written for platform API 0.4 and later, and the SBOM files only for buildpack
+// API 0.7 and later.  Per-layer BOMs are written with layers.Layer.WriteBOM.
+func (b Build) WriteBOM(bom layers.BOM) error {
+	if launch := bom.Launch(); len(launch) > 0 {
+		if err := b.Layers.WriteLaunchBOM(launch); err != nil {
+			return err
+		}
+	}
+
+	if build := bom.Build(); len(build) > 0 {
+		if b.Platform.API.LessThan(buildMetadataPlatformAPI) {
+			b.Logger.Debug("build.toml is not supported by platform API %s, not writing build BOM", b.Platform.API)
+			return nil
+		}
+
+		if err := b.Layers.WriteBuildBOM(build); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // DefaultBuild creates a new instance of Build using default values.
 func DefaultBuild() (Build, error) {
-	platformRoot, err := internal.Argument(2)
+	api, err := buildpack.DefaultBuildpackAPI()
 	if err != nil {
 		return Build{}, err
 	}
 
-	logger, err := logger.DefaultLogger(platformRoot)
+	platformRoot, err := buildpack.Argument(api, 2, "CNB_PLATFORM_DIR")
 	if err != nil {
-		return Build{}, nil
+		return Build{}, err
 	}
 
-	application, err := application.DefaultApplication(logger)
+	plan, err := buildpack.Argument(api, 3, "CNB_BP_PLAN_PATH")
 	if err != nil {
 		return Build{}, err
 	}
 
-	buildpack, err := buildpack.DefaultBuildpack(logger)
+	layersRoot, err := buildpack.Argument(api, 1, "CNB_LAYERS_DIR")
 	if err != nil {
 		return Build{}, err
 	}
 
-	plan, err := internal.Argument(3)
+	logger, err := logger.DefaultLogger(platformRoot)
+	if err != nil {
+		return Build{}, nil
+	}
+
+	application, err := application.DefaultApplication(logger)
 	if err != nil {
 		return Build{}, err
 	}
 
-	layersRoot, err := internal.Argument(1)
+	buildpack, err := buildpack.DefaultBuildpack(logger)
 	if err != nil {
 		return Build{}, err
 	}
-	layers := layers.NewLayers(layersRoot, logger)
 
 	plans, err := buildpackplan.DefaultPlans(plan, logger)
 	if err != nil {
@@ -121,6 +208,11 @@ func DefaultBuild() (Build, error) {
 		return Build{}, err
 	}
 
+	layers := layers.NewLayers(layersRoot, logger)
+	layers.API = buildpack.API
+	layers.DependencyCaches = []string{buildpack.DependencyCache(), platform.DependencyCache()}
+	layers.Offline = platform.Offline()
+
 	services, err := services.DefaultServices(platform, logger)
 	if err != nil {
 		return Build{}, err
@@ -131,7 +223,7 @@ func DefaultBuild() (Build, error) {
 		return Build{}, err
 	}
 
-	writer := buildpackplan.DefaultWriter(3)
+	writer := buildpackplan.PathWriter(plan)
 
 	return Build{
 		application,
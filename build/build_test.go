@@ -23,6 +23,7 @@ import (
 	"github.com/buildpacks/libbuildpack/v2/build"
 	"github.com/buildpacks/libbuildpack/v2/buildpackplan"
 	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
 	"github.com/onsi/gomega"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -122,6 +123,182 @@ test-key = "test-value"
 `))
 		})
 
+		it("does not write the buildpack plan for buildpack API 0.5 and later", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.5"`)
+			internal.WriteTestFile(t, filepath.Join(root, "plan.toml"), `[[entries]]
+  name = "test-entry"
+`)
+
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(b.Success(buildpackplan.Plan{Name: "other-entry"})).To(gomega.Equal(build.SuccessStatusCode))
+
+			g.Expect(filepath.Join(root, "plan.toml")).To(internal.HaveContent(`[[entries]]
+  name = "test-entry"
+`))
+		})
+
+		it("writes launch and build BOMs", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.TouchTestFile(t, root, "buildpack.toml")
+			internal.TouchTestFile(t, root, "plan.toml")
+
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(b.WriteBOM(layers.BOM{
+				{Name: "test-launch", Version: "1.0.0", Launch: true},
+				{Name: "test-build", Version: "2.0.0", Build: true},
+			})).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(root, "layers", "launch.toml")).To(internal.HaveContent(`[[bom]]
+  name = "test-launch"
+  version = "1.0.0"
+`))
+			g.Expect(filepath.Join(root, "layers", "build.toml")).To(internal.HaveContent(`[[bom]]
+  name = "test-build"
+  version = "2.0.0"
+`))
+			g.Expect(filepath.Join(root, "layers", "launch.sbom.cdx.json")).NotTo(gomega.BeAnExistingFile())
+			g.Expect(filepath.Join(root, "layers", "build.sbom.spdx.json")).NotTo(gomega.BeAnExistingFile())
+		})
+
+		it("does not write the build BOM before platform API 0.4", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceEnv(t, "CNB_PLATFORM_API", "0.3")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.TouchTestFile(t, root, "buildpack.toml")
+			internal.TouchTestFile(t, root, "plan.toml")
+
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(b.WriteBOM(layers.BOM{{Name: "test-build", Version: "2.0.0", Build: true}})).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(root, "layers", "build.toml")).NotTo(gomega.BeAnExistingFile())
+		})
+
+		it("writes unmet plan entries and the BOM on completion", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.5"`)
+			internal.WriteTestFile(t, filepath.Join(root, "plan.toml"), `[[entries]]
+  name = "test-entry"
+
+[[entries]]
+  name = "test-unmet"
+`)
+
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(b.Complete(build.Result{
+				Unmet: []string{"test-unmet"},
+				BOM:   layers.BOM{{Name: "test-build", Version: "2.0.0", Build: true}},
+			})).To(gomega.Equal(build.SuccessStatusCode))
+
+			g.Expect(filepath.Join(root, "layers", "build.toml")).To(internal.HaveContent(`[[unmet]]
+  name = "test-unmet"
+
+[[bom]]
+  name = "test-build"
+  [bom.metadata]
+    version = "2.0.0"
+`))
+		})
+
+		it("returns an error for unmet plan entries that are not in the buildpack plan", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.5"`)
+			internal.TouchTestFile(t, root, "plan.toml")
+
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			_, err = b.Complete(build.Result{Unmet: []string{"test-unmet"}})
+			g.Expect(err).To(gomega.MatchError("unmet plan entry test-unmet is not in the buildpack plan"))
+		})
+
+		it("returns an error for unmet plan entries before buildpack API 0.5", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.4"`)
+			internal.WriteTestFile(t, filepath.Join(root, "plan.toml"), `[[entries]]
+  name = "test-unmet"
+`)
+
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			_, err = b.Complete(build.Result{
+				Unmet: []string{"test-unmet"},
+				BOM:   layers.BOM{{Name: "test-launch", Version: "1.0.0", Launch: true}},
+			})
+			g.Expect(err).To(gomega.MatchError("unmet plan entries require buildpack API 0.5 or later, buildpack API is 0.4"))
+
+			g.Expect(filepath.Join(root, "layers", "launch.toml")).NotTo(gomega.BeAnExistingFile())
+			g.Expect(filepath.Join(root, "layers", "build.toml")).NotTo(gomega.BeAnExistingFile())
+		})
+
+		it("does not write unmet plan entries before platform API 0.4", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceEnv(t, "CNB_PLATFORM_API", "0.3")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.5"`)
+			internal.WriteTestFile(t, filepath.Join(root, "plan.toml"), `[[entries]]
+  name = "test-unmet"
+`)
+
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(b.Complete(build.Result{
+				Unmet: []string{"test-unmet"},
+				BOM:   layers.BOM{{Name: "test-build", Version: "2.0.0", Build: true}},
+			})).To(gomega.Equal(build.SuccessStatusCode))
+
+			g.Expect(filepath.Join(root, "layers", "build.toml")).NotTo(gomega.BeAnExistingFile())
+		})
+
+		it("reads arguments from the environment for buildpack API 0.8 and later", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceEnv(t, "CNB_PLATFORM_API", "0.9")()
+			defer internal.ReplaceEnv(t, "CNB_LAYERS_DIR", filepath.Join(root, "environment-layers"))()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.TouchTestFile(t, root, "plan.toml")
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.7"`)
+			b, err := build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(b.Layers.Root).To(gomega.Equal(filepath.Join(root, "layers")))
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.8"`)
+			b, err = build.DefaultBuild()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(b.Layers.Root).To(gomega.Equal(filepath.Join(root, "environment-layers")))
+		})
+
 		it("returns code when failing", func() {
 			defer internal.ReplaceWorkingDirectory(t, root)()
 			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
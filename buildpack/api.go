@@ -0,0 +1,65 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buildpack
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+var (
+	// DefaultAPI is the buildpack API version assumed when buildpack.toml does not declare one.
+	DefaultAPI = version.MustParse("0.2")
+
+	// MinimumAPI is the lowest buildpack API version supported by this library.
+	MinimumAPI = version.MustParse("0.2")
+
+	// MaximumAPI is the highest buildpack API version supported by this library.
+	MaximumAPI = version.MustParse("0.9")
+
+	// EnvironmentArgumentsAPI is the lowest buildpack API version to which the lifecycle provides its arguments in
+	// environment variables as well as positionally.
+	EnvironmentArgumentsAPI = version.MustParse("0.8")
+)
+
+// Argument returns a lifecycle argument.  From buildpack API 0.8, a value in the named environment variable (e.g.
+// CNB_LAYERS_DIR) takes precedence over the positional argument at os.Args[<INDEX>].
+func Argument(api version.Version, index int, name string) (string, error) {
+	if !api.LessThan(EnvironmentArgumentsAPI) {
+		if s, ok := os.LookupEnv(name); ok && s != "" {
+			return s, nil
+		}
+	}
+
+	return internal.Argument(index)
+}
+
+func resolveAPI(api version.Version) (version.Version, error) {
+	if api.IsZero() {
+		return DefaultAPI, nil
+	}
+
+	if api.LessThan(MinimumAPI) || api.GreaterThan(MaximumAPI) {
+		return version.Version{}, fmt.Errorf("buildpack API %s is not supported, supported versions are %s to %s",
+			api, MinimumAPI, MaximumAPI)
+	}
+
+	return api, nil
+}
@@ -25,10 +25,14 @@ import (
 	"github.com/BurntSushi/toml"
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/version"
 )
 
 // Buildpack represents the metadata associated with a buildpack.
 type Buildpack struct {
+	// API is the buildpack API version the buildpack implements.  Defaults to DefaultAPI if not declared.
+	API version.Version `toml:"api"`
+
 	// Info is information about the buildpack.
 	Info Info `toml:"buildpack"`
 
@@ -44,6 +48,22 @@ type Buildpack struct {
 	logger logger.Logger
 }
 
+// Dependencies returns the dependencies declared in the buildpack metadata.
+func (b Buildpack) Dependencies() (Dependencies, error) {
+	d, err := b.Metadata.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	b.logger.Debug("Dependencies: %s", d)
+	return d, nil
+}
+
+// DependencyCache returns the path to the dependency cache bundled with the buildpack.
+func (b Buildpack) DependencyCache() string {
+	return filepath.Join(b.Root, "dependency-cache")
+}
+
 // New creates an instance of Buildpack given a root dir and a logger extracting the contents of the buildpack.toml file in the root
 // of the buildpack.
 func New(rootDir string, logger logger.Logger) (Buildpack, error) {
@@ -57,6 +77,10 @@ func New(rootDir string, logger logger.Logger) (Buildpack, error) {
 		return Buildpack{}, err
 	}
 
+	if b.API, err = resolveAPI(b.API); err != nil {
+		return Buildpack{}, err
+	}
+
 	logger.Debug("Buildpack: %#v", b)
 	return b, nil
 }
@@ -81,10 +105,33 @@ func DefaultBuildpack(logger logger.Logger) (Buildpack, error) {
 		return Buildpack{}, err
 	}
 
+	if b.API, err = resolveAPI(b.API); err != nil {
+		return Buildpack{}, err
+	}
+
 	logger.Debug("Buildpack: %#v", b)
 	return b, nil
 }
 
+// DefaultBuildpackAPI returns the buildpack API version of the buildpack.toml found by DefaultBuildpack, without
+// creating a Buildpack.
+func DefaultBuildpackAPI() (version.Version, error) {
+	f, err := findBuildpackTOML()
+	if err != nil {
+		return version.Version{}, err
+	}
+
+	var b struct {
+		API version.Version `toml:"api"`
+	}
+
+	if _, err := toml.DecodeFile(f, &b); err != nil {
+		return version.Version{}, err
+	}
+
+	return resolveAPI(b.API)
+}
+
 func findBuildpackTOML() (string, error) {
 	path, err := internal.Argument(0)
 	if err != nil {
@@ -23,6 +23,7 @@ import (
 	"github.com/buildpacks/libbuildpack/v2/buildpack"
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/version"
 	"github.com/onsi/gomega"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -52,6 +53,7 @@ test-key = "test-value"
 `)
 
 			g.Expect(buildpack.DefaultBuildpack(logger.Logger{})).To(gomega.Equal(buildpack.Buildpack{
+				API: buildpack.DefaultAPI,
 				Info: buildpack.Info{
 					ID:      "buildpack-id",
 					Name:    "buildpack-name",
@@ -68,5 +70,64 @@ test-key = "test-value"
 				Root:     root,
 			}))
 		})
+
+		it("reads the default buildpack API version", func() {
+			root := internal.ScratchDir(t, "buildpack")
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.8"`)
+
+			api, err := buildpack.DefaultBuildpackAPI()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(api.Equal(version.MustParse("0.8"))).To(gomega.BeTrue())
+		})
+
+		it("unmarshals the buildpack API version", func() {
+			root := internal.ScratchDir(t, "buildpack")
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.6"
+
+[buildpack]
+id = "buildpack-id"
+`)
+
+			b, err := buildpack.New(root, logger.Logger{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(b.API.Equal(version.MustParse("0.6"))).To(gomega.BeTrue())
+		})
+
+		it("returns an error for an unsupported buildpack API version", func() {
+			root := internal.ScratchDir(t, "buildpack")
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.1"
+
+[buildpack]
+id = "buildpack-id"
+`)
+
+			_, err := buildpack.New(root, logger.Logger{})
+			g.Expect(err).To(gomega.MatchError("buildpack API 0.1 is not supported, supported versions are 0.2 to 0.9"))
+		})
+
+		it("supports buildpack API 0.9", func() {
+			root := internal.ScratchDir(t, "buildpack")
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.9"`)
+
+			b, err := buildpack.New(root, logger.Logger{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(b.API.Equal(version.MustParse("0.9"))).To(gomega.BeTrue())
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.10"`)
+
+			_, err = buildpack.New(root, logger.Logger{})
+			g.Expect(err).To(gomega.MatchError("buildpack API 0.10 is not supported, supported versions are 0.2 to 0.9"))
+		})
+
+		it("prefers environment arguments from buildpack API 0.8", func() {
+			defer internal.ReplaceArgs(t, "test", "test-argument")()
+			defer internal.ReplaceEnv(t, "CNB_LAYERS_DIR", "test-environment")()
+
+			g.Expect(buildpack.Argument(version.MustParse("0.7"), 1, "CNB_LAYERS_DIR")).To(gomega.Equal("test-argument"))
+			g.Expect(buildpack.Argument(version.MustParse("0.8"), 1, "CNB_LAYERS_DIR")).To(gomega.Equal("test-environment"))
+		})
 	}, spec.Report(report.Terminal{}))
 }
@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buildpack
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/buildpacks/libbuildpack/v2/stack"
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+// AnyStack is the stack identifier that matches every stack.
+const AnyStack = stack.Stack("*")
+
+// Dependency represents a buildpack dependency.
+type Dependency struct {
+	// ID is the dependency ID.
+	ID string `toml:"id"`
+
+	// Name is the dependency name.
+	Name string `toml:"name"`
+
+	// Version is the dependency version.
+	Version string `toml:"version"`
+
+	// URI is the dependency URI.
+	URI string `toml:"uri"`
+
+	// SHA256 is the hash of the dependency.
+	SHA256 string `toml:"sha256"`
+
+	// Stacks are the stacks the dependency is compatible with.
+	Stacks []stack.Stack `toml:"stacks"`
+
+	// Licenses are the licenses the dependency is distributed under.
+	Licenses Licenses `toml:"licenses"`
+
+	// PURL is the package URL that identifies the dependency.  Optional.
+	PURL string `toml:"purl,omitempty"`
+
+	// CPEs are the Common Platform Enumerators that identify the dependency.  Optional.
+	CPEs []string `toml:"cpes,omitempty"`
+
+	// DeprecationDate is the date that the dependency will be deprecated.  Optional.
+	DeprecationDate time.Time `toml:"deprecation_date,omitempty"`
+}
+
+// SupportsStack returns whether the dependency is compatible with a specific stack.
+func (d Dependency) SupportsStack(stack stack.Stack) bool {
+	for _, s := range d.Stacks {
+		if s == stack || s == AnyStack {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String makes Dependency satisfy the Stringer interface.
+func (d Dependency) String() string {
+	return fmt.Sprintf("%s %s %v", d.ID, d.Version, d.Stacks)
+}
+
+// Dependencies is a collection of Dependency instances.
+type Dependencies []Dependency
+
+// Best returns the best (highest version) dependency for a given id, version constraint, and stack.  An empty version
+// constraint matches every version.  If no dependency matches, the returned error lists the candidates that were
+// considered.
+func (d Dependencies) Best(id string, versionConstraint string, stack stack.Stack) (Dependency, error) {
+	c, err := version.NewConstraints(versionConstraint)
+	if err != nil {
+		return Dependency{}, err
+	}
+
+	return d.BestSatisfying(id, c, stack)
+}
+
+// BestSatisfying returns the best (highest version) dependency for a given id and stack that satisfies every version
+// constraint.  This is typically used with the constraints of all of the buildpack plans that requested a dependency.
+// Dependencies whose version cannot be parsed are skipped.  If no dependency matches, the returned error lists the
+// candidates that were considered and any that were skipped.
+func (d Dependencies) BestSatisfying(id string, constraints version.Constraints, stack stack.Stack) (Dependency, error) {
+	var (
+		best       Dependency
+		bestV      version.Version
+		found      bool
+		candidates Dependencies
+		invalid    Dependencies
+	)
+
+	for _, dependency := range d {
+		if dependency.ID != id {
+			continue
+		}
+
+		v, err := version.NewVersion(dependency.Version)
+		if err != nil {
+			invalid = append(invalid, dependency)
+			continue
+		}
+		candidates = append(candidates, dependency)
+
+		if !constraints.Check(v) || !dependency.SupportsStack(stack) {
+			continue
+		}
+
+		if !found || v.GreaterThan(bestV) {
+			best, bestV, found = dependency, v, true
+		}
+	}
+
+	if !found && len(invalid) > 0 {
+		return Dependency{}, fmt.Errorf("no valid dependencies for %s, %s, and %s in %s, skipped invalid versions in %s",
+			id, constraints, stack, candidates, invalid)
+	}
+
+	if !found {
+		return Dependency{}, fmt.Errorf("no valid dependencies for %s, %s, and %s in %s", id, constraints, stack, candidates)
+	}
+
+	return best, nil
+}
+
+// String makes Dependencies satisfy the Stringer interface.
+func (d Dependencies) String() string {
+	var s []string
+	for _, dependency := range d {
+		s = append(s, dependency.String())
+	}
+
+	return fmt.Sprintf("[%s]", strings.Join(s, ", "))
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buildpack_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/stack"
+	"github.com/buildpacks/libbuildpack/v2/version"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestDependencies(t *testing.T) {
+	spec.Run(t, "Dependencies", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		it("decodes dependencies from buildpack.toml", func() {
+			root := internal.ScratchDir(t, "buildpack")
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `[buildpack]
+id = "buildpack-id"
+
+[[metadata.dependencies]]
+id = "test-id"
+name = "test-name"
+version = "1.0.0"
+uri = "https://localhost/test-uri"
+sha256 = "test-sha256"
+stacks = ["test-stack-1", "test-stack-2"]
+purl = "pkg:generic/test-name@1.0.0"
+cpes = ["cpe:2.3:a:test:test-name:1.0.0:*:*:*:*:*:*:*"]
+deprecation_date = 2021-04-01T00:00:00Z
+
+  [[metadata.dependencies.licenses]]
+  type = "Apache-2.0"
+  uri = "https://localhost/test-license"
+`)
+
+			b, err := buildpack.New(root, logger.Logger{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(b.Dependencies()).To(gomega.Equal(buildpack.Dependencies{
+				{
+					ID:              "test-id",
+					Name:            "test-name",
+					Version:         "1.0.0",
+					URI:             "https://localhost/test-uri",
+					SHA256:          "test-sha256",
+					Stacks:          []stack.Stack{"test-stack-1", "test-stack-2"},
+					Licenses:        buildpack.Licenses{{Type: "Apache-2.0", URI: "https://localhost/test-license"}},
+					PURL:            "pkg:generic/test-name@1.0.0",
+					CPEs:            []string{"cpe:2.3:a:test:test-name:1.0.0:*:*:*:*:*:*:*"},
+					DeprecationDate: time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC),
+				},
+			}))
+		})
+
+		it("is empty without dependencies", func() {
+			g.Expect(buildpack.Metadata{}.Dependencies()).To(gomega.BeEmpty())
+		})
+
+		it("returns the best dependency", func() {
+			d := buildpack.Dependencies{
+				{ID: "test-id", Version: "1.0.0", Stacks: []stack.Stack{"test-stack"}},
+				{ID: "test-id", Version: "1.1.0", Stacks: []stack.Stack{"test-stack"}},
+				{ID: "test-id", Version: "2.0.0", Stacks: []stack.Stack{"test-stack"}},
+				{ID: "test-id", Version: "1.2.0", Stacks: []stack.Stack{"other-stack"}},
+				{ID: "other-id", Version: "1.3.0", Stacks: []stack.Stack{"test-stack"}},
+			}
+
+			g.Expect(d.Best("test-id", "1.*", "test-stack")).To(gomega.Equal(d[1]))
+			g.Expect(d.Best("test-id", "", "test-stack")).To(gomega.Equal(d[2]))
+		})
+
+		it("returns the best dependency satisfying all constraints", func() {
+			d := buildpack.Dependencies{
+				{ID: "test-id", Version: "11.0.3", Stacks: []stack.Stack{"test-stack"}},
+				{ID: "test-id", Version: "11.0.7", Stacks: []stack.Stack{"test-stack"}},
+				{ID: "test-id", Version: "12.0.0", Stacks: []stack.Stack{"test-stack"}},
+			}
+
+			c, err := version.NewConstraints("~> 11.0", ">= 11.0.4")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(d.BestSatisfying("test-id", c, "test-stack")).To(gomega.Equal(d[1]))
+		})
+
+		it("matches the wildcard stack", func() {
+			d := buildpack.Dependencies{
+				{ID: "test-id", Version: "1.0.0", Stacks: []stack.Stack{"*"}},
+			}
+
+			g.Expect(d.Best("test-id", "1.0.0", "test-stack")).To(gomega.Equal(d[0]))
+		})
+
+		it("lists candidates when no dependency matches", func() {
+			d := buildpack.Dependencies{
+				{ID: "test-id", Version: "1.0.0", Stacks: []stack.Stack{"test-stack"}},
+				{ID: "other-id", Version: "2.0.0", Stacks: []stack.Stack{"test-stack"}},
+			}
+
+			_, err := d.Best("test-id", "2.*", "test-stack")
+			g.Expect(err).To(gomega.MatchError("no valid dependencies for test-id, 2.*, and test-stack in [test-id 1.0.0 [test-stack]]"))
+		})
+
+		it("skips dependencies with invalid versions", func() {
+			d := buildpack.Dependencies{
+				{ID: "test-id", Version: "1.0.0", Stacks: []stack.Stack{"test-stack"}},
+				{ID: "test-id", Version: "invalid-version", Stacks: []stack.Stack{"test-stack"}},
+			}
+
+			g.Expect(d.Best("test-id", "1.*", "test-stack")).To(gomega.Equal(d[0]))
+
+			_, err := d.Best("test-id", "2.*", "test-stack")
+			g.Expect(err).To(gomega.MatchError("no valid dependencies for test-id, 2.*, and test-stack in [test-id 1.0.0 [test-stack]], " +
+				"skipped invalid versions in [test-id invalid-version [test-stack]]"))
+		})
+
+		it("returns an error for an invalid constraint", func() {
+			_, err := buildpack.Dependencies{}.Best("test-id", "invalid-constraint", "test-stack")
+			g.Expect(err).To(gomega.HaveOccurred())
+		})
+	}, spec.Report(report.Terminal{}))
+}
@@ -16,5 +16,34 @@
 
 package buildpack
 
+import (
+	"bytes"
+
+	"github.com/BurntSushi/toml"
+)
+
 // Metadata is additional metadata included in the buildpack
 type Metadata map[string]interface{}
+
+// Dependencies decodes the [[metadata.dependencies]] tables of the buildpack metadata into a typed collection.
+func (m Metadata) Dependencies() (Dependencies, error) {
+	raw, ok := m["dependencies"]
+	if !ok {
+		return Dependencies{}, nil
+	}
+
+	b := &bytes.Buffer{}
+	if err := toml.NewEncoder(b).Encode(map[string]interface{}{"dependencies": raw}); err != nil {
+		return nil, err
+	}
+
+	var in struct {
+		Dependencies Dependencies `toml:"dependencies"`
+	}
+
+	if _, err := toml.Decode(b.String(), &in); err != nil {
+		return nil, err
+	}
+
+	return in.Dependencies, nil
+}
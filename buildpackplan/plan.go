@@ -17,10 +17,12 @@
 package buildpackplan
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/version"
 )
 
 // Plan represents a contractual buildpack plan.
@@ -35,6 +37,17 @@ type Plan struct {
 	Metadata Metadata `toml:"metadata,omitempty"`
 }
 
+// Constraint returns the version constraint of the plan.  The constraint is taken from Version, falling back to a
+// version key in Metadata.  An empty version matches every version.
+func (p Plan) Constraint() (version.Constraint, error) {
+	v := p.Version
+	if v == "" {
+		v, _ = p.Metadata["version"].(string)
+	}
+
+	return version.NewConstraint(v)
+}
+
 // Metadata is the metadata of the plan.
 type Metadata map[string]interface{}
 
@@ -44,6 +57,38 @@ type Plans struct {
 	Entries []Plan `toml:"entries,omitempty"`
 }
 
+// Constraints returns the version constraints of every plan with a given name.  A version satisfying all of the
+// constraints satisfies every buildpack that requested the plan.
+func (p Plans) Constraints(name string) (version.Constraints, error) {
+	var c version.Constraints
+
+	for _, plan := range p.Entries {
+		if plan.Name != name {
+			continue
+		}
+
+		constraint, err := plan.Constraint()
+		if err != nil {
+			return nil, fmt.Errorf("plan %s: %w", name, err)
+		}
+
+		c = append(c, constraint)
+	}
+
+	return c, nil
+}
+
+// Contains returns whether there is a plan with a given name.
+func (p Plans) Contains(name string) bool {
+	for _, plan := range p.Entries {
+		if plan.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
 // DefaultPlans creates a new instance of Plans, unmarshalling it from a TOML file.
 func DefaultPlans(path string, logger logger.Logger) (Plans, error) {
 	in, err := os.Open(path)
@@ -60,5 +60,28 @@ func TestBuildpackPlan(t *testing.T) {
 			}))
 		})
 
+		it("returns constraints for all plans with a name", func() {
+			p := buildpackplan.Plans{
+				Entries: []buildpackplan.Plan{
+					{Name: "test-entry", Version: "11.*"},
+					{Name: "test-entry", Metadata: buildpackplan.Metadata{"version": ">= 11.0.4"}},
+					{Name: "other-entry", Version: "8.*"},
+				},
+			}
+
+			c, err := p.Constraints("test-entry")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			v, ok := c.Highest("8.0.1", "11.0.3", "11.0.7", "12.0.0")
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(v.String()).To(gomega.Equal("11.0.7"))
+		})
+
+		it("returns an error for an invalid plan version", func() {
+			_, err := buildpackplan.Plans{Entries: []buildpackplan.Plan{{Name: "test-entry", Version: "invalid-version"}}}.
+				Constraints("test-entry")
+			g.Expect(err).To(gomega.HaveOccurred())
+		})
+
 	}, spec.Report(report.Terminal{}))
 }
@@ -0,0 +1,215 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buildpackplan
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+const (
+	// VersionSource is the metadata key that records where the version of a plan came from (e.g. BP_JAVA_VERSION or
+	// buildpack.yml).
+	VersionSource = "version-source"
+
+	versionKey = "version"
+)
+
+// flagKeys are the metadata keys that are combined with a logical or when plans are merged.
+var flagKeys = map[string]bool{"build": true, "launch": true, "cache": true}
+
+// Resolver merges plans with the same name into a single plan.
+type Resolver struct {
+	// Priorities are the version sources in order of decreasing priority (e.g. BP_JAVA_VERSION, buildpack.yml).  A
+	// version from a source that is not listed has a lower priority than every listed source.
+	Priorities []string
+}
+
+// ConflictError is returned when plans with the same name cannot be merged.
+type ConflictError struct {
+	// Name is the name of the plans.
+	Name string
+
+	// Key is the conflicting field or metadata key.
+	Key string
+
+	// Values are the conflicting values.
+	Values [2]interface{}
+
+	// Sources are descriptions of the plans that declared each value.
+	Sources [2]string
+}
+
+func (c ConflictError) Error() string {
+	return fmt.Sprintf("conflicting %s for %s: %v from %s and %v from %s",
+		c.Key, c.Name, c.Values[0], c.Sources[0], c.Values[1], c.Sources[1])
+}
+
+// Resolve merges every plan with a name into a single plan.  The version with the highest priority source wins, flag
+// metadata (build, launch, cache) is combined with a logical or, and all other metadata is unioned.  If no plan has the
+// name, false is returned.  A ConflictError is returned if two plans with the highest priority declare different
+// versions or if two plans declare different values for the same metadata key.  Versions from lower priority sources
+// never conflict.
+func (r Resolver) Resolve(plans Plans, name string) (Plan, bool, error) {
+	var (
+		candidates []Plan
+		indices    []int
+	)
+	for i, p := range plans.Entries {
+		if p.Name == name {
+			candidates, indices = append(candidates, p), append(indices, i)
+		}
+	}
+
+	if len(candidates) == 0 {
+		return Plan{}, false, nil
+	}
+
+	winner := -1
+	for i, p := range candidates {
+		if planVersion(p) != "" && (winner < 0 || r.priority(p) < r.priority(candidates[winner])) {
+			winner = i
+		}
+	}
+
+	if winner >= 0 {
+		for i, p := range candidates {
+			if v := planVersion(p); v != "" && r.priority(p) == r.priority(candidates[winner]) &&
+				v != planVersion(candidates[winner]) {
+
+				return Plan{}, false, ConflictError{
+					Name:    name,
+					Key:     versionKey,
+					Values:  [2]interface{}{planVersion(candidates[winner]), v},
+					Sources: [2]string{r.source(indices[winner], candidates[winner]), r.source(indices[i], p)},
+				}
+			}
+		}
+	}
+
+	merged := Plan{Name: name, Metadata: Metadata{}}
+	sources := make(map[string]string)
+
+	for i, p := range candidates {
+		source := r.source(indices[i], p)
+
+		for _, k := range sortedKeys(p.Metadata) {
+			v := p.Metadata[k]
+
+			if k == versionKey || k == VersionSource {
+				continue
+			}
+
+			existing, ok := merged.Metadata[k]
+			switch {
+			case !ok:
+				merged.Metadata[k], sources[k] = v, source
+			case flagKeys[k]:
+				merged.Metadata[k] = truthy(existing) || truthy(v)
+			case !reflect.DeepEqual(existing, v):
+				return Plan{}, false, ConflictError{
+					Name:    name,
+					Key:     fmt.Sprintf("metadata %s", k),
+					Values:  [2]interface{}{existing, v},
+					Sources: [2]string{sources[k], source},
+				}
+			}
+		}
+	}
+
+	if winner >= 0 {
+		merged.Version = planVersion(candidates[winner])
+		if s, ok := candidates[winner].Metadata[VersionSource]; ok {
+			merged.Metadata[VersionSource] = s
+		}
+	}
+
+	if len(merged.Metadata) == 0 {
+		merged.Metadata = nil
+	}
+
+	return merged, true, nil
+}
+
+// ResolveAll merges the plans for every name, in the order that each name first appears.
+func (r Resolver) ResolveAll(plans Plans) (Plans, error) {
+	var resolved Plans
+	seen := make(map[string]bool)
+
+	for _, p := range plans.Entries {
+		if seen[p.Name] {
+			continue
+		}
+		seen[p.Name] = true
+
+		merged, _, err := r.Resolve(plans, p.Name)
+		if err != nil {
+			return Plans{}, err
+		}
+
+		resolved.Entries = append(resolved.Entries, merged)
+	}
+
+	return resolved, nil
+}
+
+// priority returns the priority of the plan's version source.  Lower values have higher priority.
+func (r Resolver) priority(plan Plan) int {
+	s, _ := plan.Metadata[VersionSource].(string)
+
+	for i, p := range r.Priorities {
+		if p == s {
+			return i
+		}
+	}
+
+	return len(r.Priorities)
+}
+
+// source describes a plan by its index in Plans.Entries and its version source, if any.
+func (r Resolver) source(index int, plan Plan) string {
+	if s, ok := plan.Metadata[VersionSource].(string); ok && s != "" {
+		return fmt.Sprintf("entry %d (%s)", index, s)
+	}
+
+	return fmt.Sprintf("entry %d", index)
+}
+
+func planVersion(plan Plan) string {
+	if plan.Version != "" {
+		return plan.Version
+	}
+
+	v, _ := plan.Metadata[versionKey].(string)
+	return v
+}
+
+func truthy(v interface{}) bool {
+	b, ok := v.(bool)
+	return ok && b
+}
+
+func sortedKeys(m Metadata) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buildpackplan_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpackplan"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestResolver(t *testing.T) {
+	spec.Run(t, "Resolver", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		resolver := buildpackplan.Resolver{Priorities: []string{"BP_TEST_VERSION", "buildpack.yml"}}
+
+		it("returns false when there are no plans with the name", func() {
+			_, ok, err := resolver.Resolve(buildpackplan.Plans{}, "test-name")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeFalse())
+		})
+
+		it("picks the version with the highest priority and unions metadata", func() {
+			plan, ok, err := resolver.Resolve(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "test-name", Version: "1.0.0", Metadata: buildpackplan.Metadata{"build": true}},
+				{Name: "test-name", Metadata: buildpackplan.Metadata{"version": "2.0.0", buildpackplan.VersionSource: "BP_TEST_VERSION", "launch": true}},
+				{Name: "test-name", Version: "3.0.0", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "buildpack.yml", "test-key": "test-value"}},
+				{Name: "other-name", Version: "4.0.0"},
+			}}, "test-name")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeTrue())
+
+			g.Expect(plan).To(gomega.Equal(buildpackplan.Plan{
+				Name:    "test-name",
+				Version: "2.0.0",
+				Metadata: buildpackplan.Metadata{
+					"build":                     true,
+					"launch":                    true,
+					"test-key":                  "test-value",
+					buildpackplan.VersionSource: "BP_TEST_VERSION",
+				},
+			}))
+		})
+
+		it("combines flags with a logical or", func() {
+			plan, _, err := resolver.Resolve(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "test-name", Metadata: buildpackplan.Metadata{"launch": false}},
+				{Name: "test-name", Metadata: buildpackplan.Metadata{"launch": true}},
+			}}, "test-name")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(plan.Metadata).To(gomega.Equal(buildpackplan.Metadata{"launch": true}))
+		})
+
+		it("reports conflicting versions with the same priority", func() {
+			_, _, err := resolver.Resolve(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "test-name", Version: "1.0.0", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "buildpack.yml"}},
+				{Name: "test-name", Version: "2.0.0", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "buildpack.yml"}},
+			}}, "test-name")
+
+			g.Expect(err).To(gomega.MatchError(
+				"conflicting version for test-name: 1.0.0 from entry 0 (buildpack.yml) and 2.0.0 from entry 1 (buildpack.yml)"))
+			g.Expect(err).To(gomega.BeAssignableToTypeOf(buildpackplan.ConflictError{}))
+		})
+
+		it("reports conflicting metadata", func() {
+			_, _, err := resolver.Resolve(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "test-name", Metadata: buildpackplan.Metadata{"test-key": "value-1"}},
+				{Name: "test-name", Metadata: buildpackplan.Metadata{"test-key": "value-2"}},
+			}}, "test-name")
+
+			g.Expect(err).To(gomega.MatchError(
+				"conflicting metadata test-key for test-name: value-1 from entry 0 and value-2 from entry 1"))
+		})
+
+		it("ignores conflicting versions below the highest priority regardless of order", func() {
+			entries := []buildpackplan.Plan{
+				{Name: "test-name", Version: "11", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "default"}},
+				{Name: "test-name", Version: "8", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "other"}},
+				{Name: "test-name", Version: "17", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "BP_TEST_VERSION"}},
+			}
+
+			for _, order := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 2, 0}} {
+				var plans buildpackplan.Plans
+				for _, i := range order {
+					plans.Entries = append(plans.Entries, entries[i])
+				}
+
+				plan, ok, err := resolver.Resolve(plans, "test-name")
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+				g.Expect(ok).To(gomega.BeTrue())
+				g.Expect(plan.Version).To(gomega.Equal("17"))
+			}
+		})
+
+		it("reports conflicts by their index in the buildpack plan", func() {
+			_, _, err := resolver.Resolve(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "other-name"},
+				{Name: "test-name", Version: "1.0.0", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "buildpack.yml"}},
+				{Name: "other-name"},
+				{Name: "test-name", Version: "2.0.0", Metadata: buildpackplan.Metadata{buildpackplan.VersionSource: "buildpack.yml"}},
+			}}, "test-name")
+
+			g.Expect(err).To(gomega.MatchError(
+				"conflicting version for test-name: 1.0.0 from entry 1 (buildpack.yml) and 2.0.0 from entry 3 (buildpack.yml)"))
+		})
+
+		it("resolves every name", func() {
+			g.Expect(resolver.ResolveAll(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "name-1", Version: "1.0.0"},
+				{Name: "name-2"},
+				{Name: "name-1", Version: "1.0.0"},
+			}})).To(gomega.Equal(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "name-1", Version: "1.0.0"},
+				{Name: "name-2"},
+			}}))
+		})
+	}, spec.Report(report.Terminal{}))
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buildplan
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Builder constructs Plans fluently.  The first alternative is the primary plan and each call to Or starts another
+// alternative:
+//
+//	plans, err := buildplan.NewBuilder().
+//		Provides("jdk").Requires("jdk").WithVersion("11.*").WithMetadata("launch", true).
+//		Or().
+//		Requires("jdk").ExternallyProvided("jdk").
+//		Build()
+type Builder struct {
+	alternatives []alternative
+	problems     []string
+}
+
+type alternative struct {
+	plan     Plan
+	external []string
+}
+
+// NewBuilder creates a new Builder with an empty primary plan.
+func NewBuilder() *Builder {
+	return &Builder{alternatives: []alternative{{}}}
+}
+
+// Provides adds provided entries to the current alternative.
+func (b *Builder) Provides(names ...string) *Builder {
+	a := b.current()
+
+	for _, n := range names {
+		if n == "" {
+			b.problem("provided entry has no name")
+			continue
+		}
+
+		for _, p := range a.plan.Provides {
+			if p.Name == n {
+				b.problem("entry %s is provided more than once", n)
+			}
+		}
+
+		a.plan.Provides = append(a.plan.Provides, Provided{Name: n})
+	}
+
+	return b
+}
+
+// Requires adds a required entry to the current alternative.  WithVersion and WithMetadata apply to the most recently
+// required entry.
+func (b *Builder) Requires(name string) *Builder {
+	if name == "" {
+		b.problem("required entry has no name")
+	}
+
+	a := b.current()
+	a.plan.Requires = append(a.plan.Requires, Required{Name: name})
+	return b
+}
+
+// WithVersion sets the version of the most recently required entry.
+func (b *Builder) WithVersion(version string) *Builder {
+	if r := b.required("WithVersion"); r != nil {
+		r.Version = version
+	}
+
+	return b
+}
+
+// WithMetadata sets a metadata key of the most recently required entry.
+func (b *Builder) WithMetadata(key string, value interface{}) *Builder {
+	if r := b.required("WithMetadata"); r != nil {
+		if r.Metadata == nil {
+			r.Metadata = Metadata{}
+		}
+		r.Metadata[key] = value
+	}
+
+	return b
+}
+
+// ExternallyProvided declares entries that are required by the current alternative and provided by other buildpacks
+// in the group.
+func (b *Builder) ExternallyProvided(names ...string) *Builder {
+	a := b.current()
+	a.external = append(a.external, names...)
+	return b
+}
+
+// Or starts a new alternative.
+func (b *Builder) Or() *Builder {
+	b.alternatives = append(b.alternatives, alternative{})
+	return b
+}
+
+// Build returns the Plans.  An error describing every problem is returned if any alternative is empty, if an entry is
+// malformed, or if a required entry is neither provided by its alternative nor declared as externally provided.
+func (b *Builder) Build() (Plans, error) {
+	problems := append([]string{}, b.problems...)
+
+	for i, a := range b.alternatives {
+		if len(a.plan.Provides) == 0 && len(a.plan.Requires) == 0 {
+			problems = append(problems, fmt.Sprintf("alternative %d: no entries are provided or required", i))
+		}
+
+		for _, r := range a.plan.Requires {
+			if r.Name != "" && !a.provides(r.Name) {
+				problems = append(problems, fmt.Sprintf("alternative %d: %s is required but not provided", i, r.Name))
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return Plans{}, fmt.Errorf("invalid build plan:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	var plans Plans
+	for i, a := range b.alternatives {
+		if i == 0 {
+			plans.Plan = a.plan
+		} else {
+			plans.Or = append(plans.Or, a.plan)
+		}
+	}
+
+	return plans, nil
+}
+
+func (b *Builder) current() *alternative {
+	return &b.alternatives[len(b.alternatives)-1]
+}
+
+func (b *Builder) required(method string) *Required {
+	a := b.current()
+
+	if len(a.plan.Requires) == 0 {
+		b.problem("%s called before Requires", method)
+		return nil
+	}
+
+	return &a.plan.Requires[len(a.plan.Requires)-1]
+}
+
+// problem records a problem with the current alternative.
+func (b *Builder) problem(format string, args ...interface{}) {
+	b.problems = append(b.problems,
+		fmt.Sprintf("alternative %d: %s", len(b.alternatives)-1, fmt.Sprintf(format, args...)))
+}
+
+func (a alternative) provides(name string) bool {
+	for _, p := range a.plan.Provides {
+		if p.Name == name {
+			return true
+		}
+	}
+
+	for _, e := range a.external {
+		if e == name {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package buildplan_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/buildplan"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestBuilder(t *testing.T) {
+	spec.Run(t, "Builder", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		it("builds plans with alternatives", func() {
+			plans, err := buildplan.NewBuilder().
+				Provides("jdk", "jre").
+				Requires("jdk").WithVersion("11.*").WithMetadata("build", true).
+				Requires("jre").WithMetadata("launch", true).
+				Or().
+				Requires("jdk").ExternallyProvided("jdk").
+				Build()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(plans).To(gomega.Equal(buildplan.Plans{
+				Plan: buildplan.Plan{
+					Provides: []buildplan.Provided{{Name: "jdk"}, {Name: "jre"}},
+					Requires: []buildplan.Required{
+						{Name: "jdk", Version: "11.*", Metadata: buildplan.Metadata{"build": true}},
+						{Name: "jre", Metadata: buildplan.Metadata{"launch": true}},
+					},
+				},
+				Or: []buildplan.Plan{
+					{Requires: []buildplan.Required{{Name: "jdk"}}},
+				},
+			}))
+
+			g.Expect(plans.Alternatives()).To(gomega.Equal([]buildplan.Plan{plans.Plan, plans.Or[0]}))
+		})
+
+		it("reports every problem", func() {
+			_, err := buildplan.NewBuilder().
+				WithVersion("1.0.0").
+				Provides("jdk", "jdk").
+				Requires("jre").
+				Or().
+				Build()
+
+			g.Expect(err).To(gomega.MatchError(`invalid build plan:
+  alternative 0: WithVersion called before Requires
+  alternative 0: entry jdk is provided more than once
+  alternative 0: jre is required but not provided
+  alternative 1: no entries are provided or required`))
+		})
+	}, spec.Report(report.Terminal{}))
+}
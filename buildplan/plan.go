@@ -16,6 +16,10 @@
 
 package buildplan
 
+import (
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
 // Plan represents a contractual build plan.
 type Plan struct {
 	// Provided represents the dependencies provided by a buildpack. Optional.
@@ -43,6 +47,11 @@ type Required struct {
 	Metadata Metadata `toml:"metadata,omitempty"`
 }
 
+// Constraint returns the version constraint of the dependency.  An empty version matches every version.
+func (r Required) Constraint() (version.Constraint, error) {
+	return version.NewConstraint(r.Version)
+}
+
 // Metadata is the metadata of a dependency.
 type Metadata map[string]interface{}
 
@@ -54,3 +63,8 @@ type Plans struct {
 	// Or are additional Plans. Optional.
 	Or []Plan `toml:"or,omitempty"`
 }
+
+// Alternatives returns the primary Plan followed by the additional Plans, in the form accepted by detect.Detect.Pass.
+func (p Plans) Alternatives() []Plan {
+	return append([]Plan{p.Plan}, p.Or...)
+}
@@ -34,3 +34,10 @@ func DefaultWriter(index int) Writer {
 		return internal.WriteTomlFile(path, 0644, plans)
 	}
 }
+
+// PathWriter writes the Plans to a file at path.
+func PathWriter(path string) Writer {
+	return func(plans Plans) error {
+		return internal.WriteTomlFile(path, 0644, plans)
+	}
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Command lifecycle-simulator runs a buildpack's bin/detect and bin/build against an application, the way the
+// lifecycle does.
+//
+//	lifecycle-simulator -buildpack <path> -application <path> -stack <id> [-platform-api <version>] [-env KEY=VALUE]...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/buildpacks/libbuildpack/v2/simulator"
+)
+
+type environment map[string]string
+
+func (e environment) String() string {
+	return fmt.Sprintf("%v", map[string]string(e))
+}
+
+func (e environment) Set(value string) error {
+	s := strings.SplitN(value, "=", 2)
+	if len(s) != 2 {
+		return fmt.Errorf("environment variable %q must be of the form KEY=VALUE", value)
+	}
+
+	e[s[0]] = s[1]
+	return nil
+}
+
+func main() {
+	s := simulator.Simulator{PlatformEnvironment: environment{}}
+
+	flag.StringVar(&s.Buildpack, "buildpack", "", "path to the root of the buildpack")
+	flag.StringVar(&s.Application, "application", ".", "path to the application")
+	flag.StringVar(&s.Root, "root", "", "path to create the platform, layers, and plan files in (default temporary directory)")
+	flag.StringVar(&s.StackID, "stack", "io.buildpacks.stacks.bionic", "id of the stack")
+	flag.StringVar(&s.PlatformAPI, "platform-api", "", "platform API version")
+	flag.Var(environment(s.PlatformEnvironment), "env", "platform environment variable of the form KEY=VALUE (repeatable)")
+	flag.Parse()
+
+	os.Exit(run(s))
+}
+
+func run(s simulator.Simulator) int {
+	if s.Buildpack == "" {
+		fmt.Fprintln(os.Stderr, "-buildpack must be set")
+		return 2
+	}
+
+	if s.Root == "" {
+		root, err := ioutil.TempDir("", "lifecycle-simulator")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		s.Root = root
+	}
+
+	d, b, err := s.Run()
+
+	fmt.Print("===> DETECTING\n", d.Stdout)
+	fmt.Fprint(os.Stderr, d.Stderr)
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if d.ExitCode != 0 {
+		fmt.Fprintf(os.Stderr, "detect failed with exit code %d\n", d.ExitCode)
+		return d.ExitCode
+	}
+
+	fmt.Print("===> BUILDING\n", b.Stdout)
+	fmt.Fprint(os.Stderr, b.Stderr)
+
+	if b.ExitCode != 0 {
+		fmt.Fprintf(os.Stderr, "build failed with exit code %d\n", b.ExitCode)
+		return b.ExitCode
+	}
+
+	fmt.Printf("===> LAYERS\n")
+	for _, l := range b.Layers {
+		fmt.Println(l.Root)
+	}
+
+	return 0
+}
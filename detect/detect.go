@@ -20,11 +20,11 @@ import (
 	"github.com/buildpacks/libbuildpack/v2/application"
 	"github.com/buildpacks/libbuildpack/v2/buildpack"
 	"github.com/buildpacks/libbuildpack/v2/buildplan"
-	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/logger"
 	"github.com/buildpacks/libbuildpack/v2/platform"
 	"github.com/buildpacks/libbuildpack/v2/services"
 	"github.com/buildpacks/libbuildpack/v2/stack"
+	"github.com/buildpacks/libbuildpack/v2/version"
 )
 
 const (
@@ -35,6 +35,8 @@ const (
 	PassStatusCode = 0
 )
 
+var requiresVersionInMetadataAPI = version.MustParse("0.3")
+
 // Detect represents all of the components available to a buildpack at detect time.
 type Detect struct {
 	// Application is the application being processed by the buildpack.
@@ -77,12 +79,12 @@ func (d Detect) Pass(plans ...buildplan.Plan) (int, error) {
 
 	p := buildplan.Plans{}
 
-	if len(plans) > 0 {
-		p.Plan = plans[0]
-	}
-
-	if len(plans) > 1 {
-		p.Or = plans[1:]
+	for i, plan := range plans {
+		if i == 0 {
+			p.Plan = d.plan(plan)
+		} else {
+			p.Or = append(p.Or, d.plan(plan))
+		}
 	}
 
 	if err := d.Writer(p); err != nil {
@@ -92,9 +94,44 @@ func (d Detect) Pass(plans ...buildplan.Plan) (int, error) {
 	return PassStatusCode, nil
 }
 
+// plan converts a plan to the format of the buildpack API.  From buildpack API 0.3 the version of a required dependency
+// is moved into its metadata.
+func (d Detect) plan(plan buildplan.Plan) buildplan.Plan {
+	if d.Buildpack.API.LessThan(requiresVersionInMetadataAPI) {
+		return plan
+	}
+
+	p := buildplan.Plan{Provides: plan.Provides}
+
+	for _, r := range plan.Requires {
+		if r.Version != "" {
+			m := buildplan.Metadata{"version": r.Version}
+			for k, v := range r.Metadata {
+				m[k] = v
+			}
+
+			r = buildplan.Required{Name: r.Name, Metadata: m}
+		}
+
+		p.Requires = append(p.Requires, r)
+	}
+
+	return p
+}
+
 // DefaultDetect creates a new instance of Detect using default values.
 func DefaultDetect() (Detect, error) {
-	platformRoot, err := internal.Argument(1)
+	api, err := buildpack.DefaultBuildpackAPI()
+	if err != nil {
+		return Detect{}, err
+	}
+
+	platformRoot, err := buildpack.Argument(api, 1, "CNB_PLATFORM_DIR")
+	if err != nil {
+		return Detect{}, err
+	}
+
+	plan, err := buildpack.Argument(api, 2, "CNB_BUILD_PLAN_PATH")
 	if err != nil {
 		return Detect{}, err
 	}
@@ -129,7 +166,7 @@ func DefaultDetect() (Detect, error) {
 		return Detect{}, err
 	}
 
-	writer := buildplan.DefaultWriter(2)
+	writer := buildplan.PathWriter(plan)
 
 	return Detect{
 		application,
@@ -194,6 +194,30 @@ test-key = "test-value"
     version = "test-version-3b"
     [or.requires.metadata]
       test-key-3b = "test-value-3b"
+`))
+		})
+
+		it("moves required versions into metadata for buildpack API 0.3 and later", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+			defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "buildpack.toml"), `api = "0.3"`)
+
+			d, err := detect.DefaultDetect()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(d.Pass(buildplan.Plan{
+				Requires: []buildplan.Required{
+					{Name: "test-required", Version: "test-version", Metadata: buildplan.Metadata{"test-key": "test-value"}},
+				},
+			})).To(gomega.Equal(detect.PassStatusCode))
+
+			g.Expect(filepath.Join(root, "plan.toml")).To(internal.HaveContent(`[[requires]]
+  name = "test-required"
+  [requires.metadata]
+    test-key = "test-value"
+    version = "test-version"
 `))
 		})
 	}, spec.Report(report.Terminal{}))
@@ -0,0 +1,68 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package execd implements the output protocol of exec.d programs.  An exec.d program is run by the lifecycle at
+// launch and prints TOML-formatted environment variables to file descriptor 3.  Programs are installed into a layer
+// with layers.Layer.WriteExecD.
+package execd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// OutputFileDescriptor is the file descriptor that exec.d programs write their output to.
+const OutputFileDescriptor = 3
+
+// Executor is called when an exec.d program runs.  It returns the environment variables to add to the process
+// environment.
+type Executor func() (map[string]string, error)
+
+// Main is the entrypoint for an exec.d program.  It runs the executor, writes the environment variables to file
+// descriptor 3, and exits.  If the executor returns an error, it is printed to stderr and the program exits with a
+// non-zero status code.
+func Main(executor Executor) {
+	out := os.NewFile(OutputFileDescriptor, "/dev/fd/3")
+
+	if err := Run(executor, out); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(0)
+}
+
+// Run runs the executor and writes the environment variables to out.
+func Run(executor Executor, out io.Writer) error {
+	env, err := executor()
+	if err != nil {
+		return err
+	}
+
+	return Write(out, env)
+}
+
+// Write writes environment variables as TOML, in the format expected from exec.d programs.
+func Write(out io.Writer, env map[string]string) error {
+	if env == nil {
+		env = map[string]string{}
+	}
+
+	return toml.NewEncoder(out).Encode(env)
+}
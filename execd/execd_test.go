@@ -0,0 +1,58 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package execd_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libbuildpack/v2/execd"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestExecD(t *testing.T) {
+	spec.Run(t, "ExecD", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		it("writes environment variables as TOML", func() {
+			b := &bytes.Buffer{}
+
+			g.Expect(execd.Run(func() (map[string]string, error) {
+				return map[string]string{"TEST_KEY": `test "value"`}, nil
+			}, b)).To(gomega.Succeed())
+
+			var env map[string]string
+			_, err := toml.Decode(b.String(), &env)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(env).To(gomega.Equal(map[string]string{"TEST_KEY": `test "value"`}))
+		})
+
+		it("returns executor errors without writing", func() {
+			b := &bytes.Buffer{}
+
+			g.Expect(execd.Run(func() (map[string]string, error) {
+				return nil, fmt.Errorf("test-error")
+			}, b)).To(gomega.MatchError("test-error"))
+			g.Expect(b.Len()).To(gomega.Equal(0))
+		})
+	}, spec.Report(report.Terminal{}))
+}
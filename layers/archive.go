@@ -0,0 +1,456 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ulikunitz/xz"
+)
+
+// Extract expands an archive into the layer's root, removing the first stripComponents path elements from each entry.
+// Tar archives (optionally compressed with gzip, xz, or bzip2) and zip archives are supported.  The format is
+// determined from the file's extension, falling back to its contents.  File modes and symbolic links are preserved.
+// Entries with absolute paths, or whose paths or link targets would escape the layer's root, either directly or through
+// symbolic links that have already been extracted, are rejected.
+func (l Layer) Extract(archive string, stripComponents int) error {
+	l.logger.Debug("Extracting %s to %s, stripping %d components", archive, l.Root, stripComponents)
+
+	if stripComponents < 0 {
+		return fmt.Errorf("strip components must not be negative, got %d", stripComponents)
+	}
+
+	if err := os.MkdirAll(l.Root, 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	format, err := archiveFormat(archive, in)
+	if err != nil {
+		return err
+	}
+
+	a, err := newArchiveExtractor(l.Root, stripComponents)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "zip":
+		s, err := in.Stat()
+		if err != nil {
+			return err
+		}
+		return a.zip(in, s.Size())
+	case "tar":
+		return a.tar(in)
+	case "tar.gz":
+		gz, err := gzip.NewReader(in)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		return a.tar(gz)
+	case "tar.xz":
+		x, err := xz.NewReader(bufio.NewReader(in))
+		if err != nil {
+			return err
+		}
+		return a.tar(x)
+	case "tar.bz2":
+		return a.tar(bzip2.NewReader(in))
+	default:
+		return fmt.Errorf("unsupported archive format for %s", archive)
+	}
+}
+
+func archiveFormat(archive string, in io.ReadSeeker) (string, error) {
+	name := strings.ToLower(archive)
+
+	switch {
+	case strings.HasSuffix(name, ".zip") || strings.HasSuffix(name, ".jar"):
+		return "zip", nil
+	case strings.HasSuffix(name, ".tar"):
+		return "tar", nil
+	case strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz"):
+		return "tar.gz", nil
+	case strings.HasSuffix(name, ".tar.xz") || strings.HasSuffix(name, ".txz"):
+		return "tar.xz", nil
+	case strings.HasSuffix(name, ".tar.bz2") || strings.HasSuffix(name, ".tbz2"):
+		return "tar.bz2", nil
+	}
+
+	header := make([]byte, 262)
+	n, err := io.ReadFull(in, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	header = header[:n]
+
+	if _, err := in.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	switch {
+	case bytes.HasPrefix(header, []byte("PK\x03\x04")):
+		return "zip", nil
+	case bytes.HasPrefix(header, []byte{0x1f, 0x8b}):
+		return "tar.gz", nil
+	case bytes.HasPrefix(header, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}):
+		return "tar.xz", nil
+	case bytes.HasPrefix(header, []byte("BZh")):
+		return "tar.bz2", nil
+	case len(header) >= 262 && bytes.HasPrefix(header[257:], []byte("ustar")):
+		return "tar", nil
+	}
+
+	return "", nil
+}
+
+type archiveExtractor struct {
+	destination     string
+	stripComponents int
+	directories     map[string]os.FileMode
+}
+
+func newArchiveExtractor(destination string, stripComponents int) (archiveExtractor, error) {
+	d, err := filepath.EvalSymlinks(destination)
+	if err != nil {
+		return archiveExtractor{}, err
+	}
+
+	return archiveExtractor{
+		destination:     d,
+		stripComponents: stripComponents,
+		directories:     make(map[string]os.FileMode),
+	}, nil
+}
+
+func (a archiveExtractor) tar(in io.Reader) error {
+	t := tar.NewReader(in)
+
+	for {
+		header, err := t.Next()
+		if err == io.EOF {
+			return a.chmodDirectories()
+		} else if err != nil {
+			return err
+		}
+
+		target, ok, err := a.target(header.Name)
+		if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := a.directory(header.Name, target, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		case tar.TypeReg, tar.TypeRegA:
+			if err := a.file(header.Name, target, t, header.FileInfo().Mode()); err != nil {
+				return err
+			}
+		case tar.TypeSymlink:
+			if err := a.symlink(header.Name, target, header.Linkname); err != nil {
+				return err
+			}
+		case tar.TypeLink:
+			if err := a.link(header.Name, target, header.Linkname); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (a archiveExtractor) zip(in io.ReaderAt, size int64) error {
+	z, err := zip.NewReader(in, size)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range z.File {
+		target, ok, err := a.target(f.Name)
+		if err != nil {
+			return err
+		} else if !ok {
+			continue
+		}
+
+		if err := a.zipEntry(f, target); err != nil {
+			return err
+		}
+	}
+
+	return a.chmodDirectories()
+}
+
+func (a archiveExtractor) zipEntry(f *zip.File, target string) error {
+	mode := f.Mode()
+
+	if mode.IsDir() {
+		return a.directory(f.Name, target, mode)
+	}
+
+	in, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if mode&os.ModeSymlink != 0 {
+		b, err := ioutil.ReadAll(in)
+		if err != nil {
+			return err
+		}
+
+		return a.symlink(f.Name, target, string(b))
+	}
+
+	return a.file(f.Name, target, in, mode)
+}
+
+// directory creates a directory.  Its mode is applied once extraction has completed so that entries can still be
+// written to directories that are not writable.
+func (a archiveExtractor) directory(name string, target string, mode os.FileMode) error {
+	path, err := a.resolve(name, target)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return err
+	}
+
+	a.directories[path] = mode.Perm()
+	return os.Chmod(path, mode.Perm()|0700)
+}
+
+func (a archiveExtractor) file(name string, target string, in io.Reader, mode os.FileMode) error {
+	path, err := a.prepare(name, target)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, mode.Perm())
+}
+
+func (a archiveExtractor) symlink(name string, target string, link string) error {
+	if filepath.IsAbs(link) {
+		return fmt.Errorf("illegal absolute link target %q in archive entry %q", link, name)
+	}
+
+	path, err := a.prepare(name, target)
+	if err != nil {
+		return err
+	}
+
+	if resolved, err := a.walk(filepath.Dir(path), link, 0); err != nil || !a.contains(resolved) {
+		return fmt.Errorf("illegal link target %q in archive entry %q", link, name)
+	}
+
+	return os.Symlink(link, path)
+}
+
+func (a archiveExtractor) link(name string, target string, link string) error {
+	source, ok, err := a.target(link)
+	if err != nil {
+		return err
+	} else if !ok {
+		return fmt.Errorf("illegal link target %q in archive entry %q", link, name)
+	}
+
+	if source, err = a.resolve(name, source); err != nil {
+		return err
+	}
+
+	path, err := a.prepare(name, target)
+	if err != nil {
+		return err
+	}
+
+	return os.Link(source, path)
+}
+
+// prepare creates the parent directory of target and removes any existing file or link at target, returning the path
+// to write to.  The parent directory is resolved through existing symbolic links and must be within the destination.
+func (a archiveExtractor) prepare(name string, target string) (string, error) {
+	parent, err := a.resolve(name, filepath.Dir(target))
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(parent, filepath.Base(target))
+
+	if s, err := os.Lstat(path); err == nil && !s.IsDir() {
+		if err := os.Remove(path); err != nil {
+			return "", err
+		}
+	} else if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// resolve resolves target, which must be within the destination, through the symbolic links that exist on disk.
+// Returns an error if the resolved path is not within the destination.
+func (a archiveExtractor) resolve(name string, target string) (string, error) {
+	rel, err := filepath.Rel(a.destination, target)
+	if err != nil {
+		return "", err
+	}
+
+	path, err := a.walk(a.destination, rel, 0)
+	if err != nil || !a.contains(path) {
+		return "", fmt.Errorf("illegal path in archive entry %q", name)
+	}
+
+	return path, nil
+}
+
+// walk resolves path relative to the fully resolved directory base, following symbolic links the way the operating
+// system would.  Path elements that do not exist are joined lexically.
+func (a archiveExtractor) walk(base string, path string, depth int) (string, error) {
+	if depth > 255 {
+		return "", fmt.Errorf("too many levels of symbolic links")
+	}
+
+	current := base
+	for _, e := range strings.Split(filepath.ToSlash(path), "/") {
+		switch e {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			continue
+		}
+
+		next := filepath.Join(current, e)
+
+		s, err := os.Lstat(next)
+		if os.IsNotExist(err) {
+			current = next
+			continue
+		} else if err != nil {
+			return "", err
+		}
+
+		if s.Mode()&os.ModeSymlink == 0 {
+			current = next
+			continue
+		}
+
+		link, err := os.Readlink(next)
+		if err != nil {
+			return "", err
+		}
+
+		if filepath.IsAbs(link) {
+			current, err = a.walk(string(filepath.Separator), link, depth+1)
+		} else {
+			current, err = a.walk(current, link, depth+1)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return current, nil
+}
+
+func (a archiveExtractor) chmodDirectories() error {
+	for path, mode := range a.directories {
+		if err := os.Chmod(path, mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// target returns the destination path for an archive entry, after stripping components.  Returns false if the entry
+// has been stripped entirely.
+func (a archiveExtractor) target(name string) (string, bool, error) {
+	if path.IsAbs(name) || filepath.IsAbs(name) {
+		return "", false, fmt.Errorf("illegal absolute path in archive entry %q", name)
+	}
+
+	var elements []string
+	for _, e := range strings.Split(filepath.ToSlash(name), "/") {
+		if e != "" && e != "." {
+			elements = append(elements, e)
+		}
+	}
+
+	if len(elements) <= a.stripComponents {
+		return "", false, nil
+	}
+
+	target := filepath.Join(a.destination, filepath.Join(elements[a.stripComponents:]...))
+	if !a.contains(target) {
+		return "", false, fmt.Errorf("illegal path in archive entry %q", name)
+	}
+
+	return target, true, nil
+}
+
+func (a archiveExtractor) contains(path string) bool {
+	rel, err := filepath.Rel(a.destination, path)
+	if err != nil {
+		return false
+	}
+
+	return rel != ".." && !strings.HasPrefix(rel, fmt.Sprintf("..%c", filepath.Separator))
+}
@@ -0,0 +1,298 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+	"github.com/ulikunitz/xz"
+)
+
+type testEntry struct {
+	name string
+	mode os.FileMode
+	link string
+	body string
+}
+
+func TestArchive(t *testing.T) {
+	spec.Run(t, "Archive", func(t *testing.T, when spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		var (
+			root  string
+			layer layers.Layer
+		)
+
+		it.Before(func() {
+			root = internal.ScratchDir(t, "archive")
+			layer = layers.Layers{Root: filepath.Join(root, "layers")}.Layer("test-layer")
+		})
+
+		writeTar := func(w io.Writer, entries ...testEntry) {
+			t.Helper()
+
+			tw := tar.NewWriter(w)
+			for _, e := range entries {
+				h := &tar.Header{Name: e.name, Mode: int64(e.mode.Perm()), Size: int64(len(e.body))}
+
+				switch {
+				case e.mode.IsDir():
+					h.Typeflag = tar.TypeDir
+				case e.mode&os.ModeSymlink != 0:
+					h.Typeflag, h.Linkname = tar.TypeSymlink, e.link
+				default:
+					h.Typeflag = tar.TypeReg
+				}
+
+				g.Expect(tw.WriteHeader(h)).To(gomega.Succeed())
+				_, err := tw.Write([]byte(e.body))
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+			}
+			g.Expect(tw.Close()).To(gomega.Succeed())
+		}
+
+		create := func(name string) *os.File {
+			t.Helper()
+
+			f, err := os.Create(filepath.Join(root, name))
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			return f
+		}
+
+		entries := []testEntry{
+			{name: "prefix/", mode: os.ModeDir | 0755},
+			{name: "prefix/bin/", mode: os.ModeDir | 0755},
+			{name: "prefix/bin/test-executable", mode: 0755, body: "test-executable"},
+			{name: "prefix/test-file", mode: 0644, body: "test-file"},
+			{name: "prefix/test-link", mode: os.ModeSymlink | 0777, link: "test-file"},
+		}
+
+		expectExtracted := func() {
+			t.Helper()
+
+			g.Expect(filepath.Join(layer.Root, "test-file")).To(internal.HaveContent("test-file"))
+			g.Expect(filepath.Join(layer.Root, "bin", "test-executable")).To(internal.HaveContent("test-executable"))
+
+			s, err := os.Stat(filepath.Join(layer.Root, "bin", "test-executable"))
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(s.Mode().Perm()).To(gomega.Equal(os.FileMode(0755)))
+
+			link, err := os.Readlink(filepath.Join(layer.Root, "test-link"))
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(link).To(gomega.Equal("test-file"))
+		}
+
+		it("extracts a tar", func() {
+			f := create("test.tar")
+			writeTar(f, entries...)
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 1)).To(gomega.Succeed())
+			expectExtracted()
+		})
+
+		it("extracts a tar.gz", func() {
+			f := create("test.tgz")
+			gz := gzip.NewWriter(f)
+			writeTar(gz, entries...)
+			g.Expect(gz.Close()).To(gomega.Succeed())
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 1)).To(gomega.Succeed())
+			expectExtracted()
+		})
+
+		it("extracts a tar.xz", func() {
+			f := create("test.tar.xz")
+			x, err := xz.NewWriter(f)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			writeTar(x, entries...)
+			g.Expect(x.Close()).To(gomega.Succeed())
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 1)).To(gomega.Succeed())
+			expectExtracted()
+		})
+
+		it("extracts a tar.bz2", func() {
+			g.Expect(layer.Extract(filepath.Join("testdata", "test.tar.bz2"), 1)).To(gomega.Succeed())
+			expectExtracted()
+		})
+
+		it("returns an error for negative strip components", func() {
+			f := create("test.tar")
+			writeTar(f, entries...)
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), -1)).To(gomega.MatchError("strip components must not be negative, got -1"))
+		})
+
+		it("extracts a zip", func() {
+			f := create("test.zip")
+			z := zip.NewWriter(f)
+			for _, e := range entries {
+				h := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+				h.SetMode(e.mode)
+
+				w, err := z.CreateHeader(h)
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				body := e.body
+				if e.link != "" {
+					body = e.link
+				}
+				_, err = w.Write([]byte(body))
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+			}
+			g.Expect(z.Close()).To(gomega.Succeed())
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 1)).To(gomega.Succeed())
+			expectExtracted()
+		})
+
+		it("detects the format from the contents", func() {
+			f := create("test-artifact")
+			gz := gzip.NewWriter(f)
+			writeTar(gz, entries...)
+			g.Expect(gz.Close()).To(gomega.Succeed())
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 1)).To(gomega.Succeed())
+			expectExtracted()
+		})
+
+		it("extracts without stripping components", func() {
+			f := create("test.tar")
+			writeTar(f, entries...)
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 0)).To(gomega.Succeed())
+			g.Expect(filepath.Join(layer.Root, "prefix", "test-file")).To(internal.HaveContent("test-file"))
+		})
+
+		it("preserves modes", func() {
+			f := create("test.tar")
+			writeTar(f,
+				testEntry{name: "test-directory/", mode: os.ModeDir | 0555},
+				testEntry{name: "test-directory/test-file", mode: 0666, body: "test-file"},
+				testEntry{name: "test-existing", mode: 0600, body: "test-existing"},
+				testEntry{name: "test-existing", mode: 0755, body: "test-existing"},
+			)
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 0)).To(gomega.Succeed())
+			defer os.Chmod(filepath.Join(layer.Root, "test-directory"), 0755)
+
+			for file, mode := range map[string]os.FileMode{
+				"test-directory":           0555,
+				"test-directory/test-file": 0666,
+				"test-existing":            0755,
+			} {
+				s, err := os.Stat(filepath.Join(layer.Root, file))
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+				g.Expect(s.Mode().Perm()).To(gomega.Equal(mode), file)
+			}
+		})
+
+		it("replaces existing symbolic links rather than writing through them", func() {
+			f := create("test.tar")
+			writeTar(f,
+				testEntry{name: "test-file", mode: 0644, body: "test-file"},
+				testEntry{name: "test-link", mode: os.ModeSymlink | 0777, link: "test-file"},
+				testEntry{name: "test-link", mode: 0644, body: "test-link"},
+			)
+			g.Expect(f.Close()).To(gomega.Succeed())
+
+			g.Expect(layer.Extract(f.Name(), 0)).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(layer.Root, "test-file")).To(internal.HaveContent("test-file"))
+			g.Expect(filepath.Join(layer.Root, "test-link")).To(internal.HaveContent("test-link"))
+		})
+
+		when("malicious archives", func() {
+
+			it("rejects path traversal", func() {
+				f := create("test.tar")
+				writeTar(f, testEntry{name: "prefix/../../evil", mode: 0644, body: "evil"})
+				g.Expect(f.Close()).To(gomega.Succeed())
+
+				g.Expect(layer.Extract(f.Name(), 0)).
+					To(gomega.MatchError(`illegal path in archive entry "prefix/../../evil"`))
+				g.Expect(filepath.Join(root, "layers", "evil")).NotTo(gomega.BeAnExistingFile())
+			})
+
+			it("rejects absolute paths", func() {
+				f := create("test.tar")
+				writeTar(f, testEntry{name: "/evil", mode: 0644, body: "evil"})
+				g.Expect(f.Close()).To(gomega.Succeed())
+
+				g.Expect(layer.Extract(f.Name(), 0)).
+					To(gomega.MatchError(`illegal absolute path in archive entry "/evil"`))
+			})
+
+			it("rejects symbolic links outside the root", func() {
+				f := create("test.tar")
+				writeTar(f, testEntry{name: "evil-link", mode: os.ModeSymlink | 0777, link: "../../.."})
+				g.Expect(f.Close()).To(gomega.Succeed())
+
+				g.Expect(layer.Extract(f.Name(), 0)).
+					To(gomega.MatchError(`illegal link target "../../.." in archive entry "evil-link"`))
+			})
+
+			it("rejects symbolic links that escape the root through existing links", func() {
+				f := create("test.tar")
+				writeTar(f,
+					testEntry{name: "a", mode: os.ModeSymlink | 0777, link: "."},
+					testEntry{name: "a/b", mode: os.ModeSymlink | 0777, link: ".."},
+					testEntry{name: "b/evil", mode: 0644, body: "evil"},
+				)
+				g.Expect(f.Close()).To(gomega.Succeed())
+
+				g.Expect(layer.Extract(f.Name(), 0)).
+					To(gomega.MatchError(`illegal link target ".." in archive entry "a/b"`))
+				g.Expect(filepath.Join(root, "layers", "evil")).NotTo(gomega.BeAnExistingFile())
+			})
+
+			it("rejects entries written through links that escape the root", func() {
+				g.Expect(os.MkdirAll(layer.Root, 0755)).To(gomega.Succeed())
+				g.Expect(os.Symlink("..", filepath.Join(layer.Root, "evil-link"))).To(gomega.Succeed())
+
+				f := create("test.tar")
+				writeTar(f, testEntry{name: "evil-link/evil", mode: 0644, body: "evil"})
+				g.Expect(f.Close()).To(gomega.Succeed())
+
+				g.Expect(layer.Extract(f.Name(), 0)).
+					To(gomega.MatchError(`illegal path in archive entry "evil-link/evil"`))
+				g.Expect(filepath.Join(root, "layers", "evil")).NotTo(gomega.BeAnExistingFile())
+			})
+		})
+	}, spec.Report(report.Terminal{}))
+}
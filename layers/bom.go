@@ -0,0 +1,203 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+var bomVersionInMetadataAPI = version.MustParse("0.5")
+
+// BOM is a collection of BOMEntry instances, describing what a buildpack has installed.
+type BOM []BOMEntry
+
+// BOMEntry is an entry in a bill of materials.
+type BOMEntry struct {
+	// Name is the name of the entry.
+	Name string
+
+	// Version is the version of the entry.  Optional.
+	Version string
+
+	// PURL is the package URL that identifies the entry.  Optional.
+	PURL string
+
+	// CPEs are the Common Platform Enumerators that identify the entry.  Optional.
+	CPEs []string
+
+	// Licenses are the SPDX identifiers of the licenses the entry is distributed under.  Optional.
+	Licenses []string
+
+	// Checksum is the checksum of the entry.  Optional.
+	Checksum Checksum
+
+	// Build indicates that the entry is available at build time.
+	Build bool
+
+	// Launch indicates that the entry is available at launch time.
+	Launch bool
+}
+
+// Checksum is a checksum of a BOMEntry.
+type Checksum struct {
+	// Algorithm is the algorithm of the checksum, in SPDX form (e.g. SHA256).
+	Algorithm string
+
+	// Hash is the hex encoded value of the checksum.
+	Hash string
+}
+
+// NewBOMEntry creates a BOMEntry describing a dependency.
+func NewBOMEntry(dependency buildpack.Dependency) BOMEntry {
+	e := BOMEntry{
+		Name:    dependency.Name,
+		Version: dependency.Version,
+		PURL:    dependency.PURL,
+		CPEs:    dependency.CPEs,
+	}
+
+	if e.Name == "" {
+		e.Name = dependency.ID
+	}
+
+	for _, l := range dependency.Licenses {
+		if l.Type != "" {
+			e.Licenses = append(e.Licenses, l.Type)
+		}
+	}
+
+	if dependency.SHA256 != "" {
+		e.Checksum = Checksum{Algorithm: "SHA256", Hash: dependency.SHA256}
+	}
+
+	return e
+}
+
+type legacyBOMEntry struct {
+	Name     string                 `toml:"name"`
+	Version  string                 `toml:"version,omitempty"`
+	Metadata map[string]interface{} `toml:"metadata,omitempty"`
+}
+
+// legacy converts the BOM to legacy [[bom]] tables.  From buildpack API 0.5 the version of an entry is moved into its
+// metadata.
+func (b BOM) legacy(api version.Version) []legacyBOMEntry {
+	var entries []legacyBOMEntry
+
+	for _, e := range b {
+		m := make(map[string]interface{})
+		v := e.Version
+
+		if !api.LessThan(bomVersionInMetadataAPI) && v != "" {
+			m["version"], v = v, ""
+		}
+
+		if e.PURL != "" {
+			m["purl"] = e.PURL
+		}
+
+		if len(e.CPEs) > 0 {
+			m["cpes"] = e.CPEs
+		}
+
+		if len(e.Licenses) > 0 {
+			m["licenses"] = e.Licenses
+		}
+
+		if e.Checksum.Hash != "" {
+			m["checksum"] = map[string]interface{}{
+				"algorithm": e.Checksum.Algorithm,
+				"hash":      e.Checksum.Hash,
+			}
+		}
+
+		entries = append(entries, legacyBOMEntry{Name: e.Name, Version: v, Metadata: m})
+	}
+
+	return entries
+}
+
+// fromLegacyBOM converts legacy [[bom]] tables written by BOM.legacy back to a BOM.  Entries that were not written by
+// BOM.legacy may not be converted completely.
+func fromLegacyBOM(entries []legacyBOMEntry) BOM {
+	var bom BOM
+
+	for _, l := range entries {
+		e := BOMEntry{Name: l.Name, Version: l.Version}
+
+		if v, ok := l.Metadata["version"].(string); ok && e.Version == "" {
+			e.Version = v
+		}
+
+		if v, ok := l.Metadata["purl"].(string); ok {
+			e.PURL = v
+		}
+
+		e.CPEs = stringSlice(l.Metadata["cpes"])
+		e.Licenses = stringSlice(l.Metadata["licenses"])
+
+		if c, ok := l.Metadata["checksum"].(map[string]interface{}); ok {
+			e.Checksum.Algorithm, _ = c["algorithm"].(string)
+			e.Checksum.Hash, _ = c["hash"].(string)
+		}
+
+		bom = append(bom, e)
+	}
+
+	return bom
+}
+
+func stringSlice(v interface{}) []string {
+	var s []string
+
+	if a, ok := v.([]interface{}); ok {
+		for _, e := range a {
+			if t, ok := e.(string); ok {
+				s = append(s, t)
+			}
+		}
+	}
+
+	return s
+}
+
+// Build returns the entries of the BOM that are available at build time.
+func (b BOM) Build() BOM {
+	var filtered BOM
+
+	for _, e := range b {
+		if e.Build {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// Launch returns the entries of the BOM that are available at launch time.
+func (b BOM) Launch() BOM {
+	var filtered BOM
+
+	for _, e := range b {
+		if e.Launch {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
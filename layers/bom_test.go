@@ -0,0 +1,211 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/buildpacks/libbuildpack/v2/version"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestBOM(t *testing.T) {
+	spec.Run(t, "BOM", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		var (
+			root  string
+			entry layers.BOMEntry
+		)
+
+		it.Before(func() {
+			root = internal.ScratchDir(t, "bom")
+			entry = layers.BOMEntry{
+				Name:     "test-name",
+				Version:  "1.0.0",
+				PURL:     "pkg:generic/test-name@1.0.0",
+				CPEs:     []string{"cpe:2.3:a:test:test-name:1.0.0:*:*:*:*:*:*:*"},
+				Licenses: []string{"Apache-2.0"},
+				Checksum: layers.Checksum{Algorithm: "SHA256", Hash: "test-sha256"},
+				Launch:   true,
+			}
+		})
+
+		readJSON := func(file string) map[string]interface{} {
+			t.Helper()
+
+			b, err := ioutil.ReadFile(file)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			var m map[string]interface{}
+			g.Expect(json.Unmarshal(b, &m)).To(gomega.Succeed())
+			return m
+		}
+
+		it("creates an entry from a dependency", func() {
+			g.Expect(layers.NewBOMEntry(buildpack.Dependency{
+				ID:       "test-id",
+				Version:  "1.0.0",
+				SHA256:   "test-sha256",
+				Licenses: buildpack.Licenses{{Type: "Apache-2.0"}, {URI: "https://localhost/test-license"}},
+				PURL:     "pkg:generic/test-name@1.0.0",
+			})).To(gomega.Equal(layers.BOMEntry{
+				Name:     "test-id",
+				Version:  "1.0.0",
+				PURL:     "pkg:generic/test-name@1.0.0",
+				Licenses: []string{"Apache-2.0"},
+				Checksum: layers.Checksum{Algorithm: "SHA256", Hash: "test-sha256"},
+			}))
+		})
+
+		it("writes CycloneDX and SPDX files for a layer", func() {
+			l := layers.Layers{Root: root, API: version.MustParse("0.7")}
+			g.Expect(l.Layer("test-layer").WriteBOM(layers.BOM{entry})).To(gomega.Succeed())
+
+			cdx := readJSON(filepath.Join(root, "test-layer.sbom.cdx.json"))
+			g.Expect(cdx).To(gomega.HaveKeyWithValue("bomFormat", "CycloneDX"))
+			g.Expect(cdx["components"]).To(gomega.Equal([]interface{}{
+				map[string]interface{}{
+					"type":     "library",
+					"name":     "test-name",
+					"version":  "1.0.0",
+					"purl":     "pkg:generic/test-name@1.0.0",
+					"cpe":      "cpe:2.3:a:test:test-name:1.0.0:*:*:*:*:*:*:*",
+					"licenses": []interface{}{map[string]interface{}{"license": map[string]interface{}{"id": "Apache-2.0"}}},
+					"hashes":   []interface{}{map[string]interface{}{"alg": "SHA-256", "content": "test-sha256"}},
+				},
+			}))
+
+			spdx := readJSON(filepath.Join(root, "test-layer.sbom.spdx.json"))
+			g.Expect(spdx).To(gomega.HaveKeyWithValue("spdxVersion", "SPDX-2.2"))
+			g.Expect(spdx).To(gomega.HaveKeyWithValue("name", "test-layer"))
+			g.Expect(spdx["packages"]).To(gomega.Equal([]interface{}{
+				map[string]interface{}{
+					"SPDXID":           "SPDXRef-Package-0-test-name",
+					"name":             "test-name",
+					"versionInfo":      "1.0.0",
+					"downloadLocation": "NOASSERTION",
+					"licenseConcluded": "NOASSERTION",
+					"licenseDeclared":  "Apache-2.0",
+					"copyrightText":    "NOASSERTION",
+					"checksums":        []interface{}{map[string]interface{}{"algorithm": "SHA256", "checksumValue": "test-sha256"}},
+					"externalRefs": []interface{}{
+						map[string]interface{}{"referenceCategory": "PACKAGE_MANAGER", "referenceType": "purl", "referenceLocator": "pkg:generic/test-name@1.0.0"},
+						map[string]interface{}{"referenceCategory": "SECURITY", "referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:test:test-name:1.0.0:*:*:*:*:*:*:*"},
+					},
+				},
+			}))
+		})
+
+		it("does not write SBOM files before buildpack API 0.7", func() {
+			l := layers.Layers{Root: root, API: version.MustParse("0.6")}
+
+			g.Expect(l.Layer("test-layer").WriteBOM(layers.BOM{entry})).To(gomega.Succeed())
+			g.Expect(l.WriteLaunchBOM(layers.BOM{entry})).To(gomega.Succeed())
+			g.Expect(l.WriteBuildBOM(layers.BOM{entry})).To(gomega.Succeed())
+
+			for _, prefix := range []string{"test-layer", "launch", "build"} {
+				g.Expect(filepath.Join(root, prefix+".sbom.cdx.json")).NotTo(gomega.BeAnExistingFile())
+				g.Expect(filepath.Join(root, prefix+".sbom.spdx.json")).NotTo(gomega.BeAnExistingFile())
+			}
+
+			g.Expect(filepath.Join(root, "launch.toml")).To(gomega.BeARegularFile())
+			g.Expect(filepath.Join(root, "build.toml")).To(gomega.BeARegularFile())
+		})
+
+		it("writes launch and build SBOM files from buildpack API 0.7", func() {
+			l := layers.Layers{Root: root, API: version.MustParse("0.7")}
+
+			g.Expect(l.WriteLaunchBOM(layers.BOM{entry})).To(gomega.Succeed())
+			g.Expect(l.WriteBuildBOM(layers.BOM{entry})).To(gomega.Succeed())
+
+			for _, prefix := range []string{"launch", "build"} {
+				g.Expect(filepath.Join(root, prefix+".sbom.cdx.json")).To(gomega.BeARegularFile())
+				g.Expect(filepath.Join(root, prefix+".sbom.spdx.json")).To(gomega.BeARegularFile())
+			}
+		})
+
+		it("writes legacy launch BOM preserving processes", func() {
+			l := layers.Layers{Root: root}
+			g.Expect(l.WriteApplicationMetadata(layers.Metadata{
+				Processes: layers.Processes{{Type: "web", Command: "command-1"}},
+			})).To(gomega.Succeed())
+
+			g.Expect(l.WriteLaunchBOM(layers.BOM{entry})).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(root, "launch.toml")).To(internal.HaveContent(`[[processes]]
+  type = "web"
+  command = "command-1"
+  direct = false
+
+[[bom]]
+  name = "test-name"
+  version = "1.0.0"
+  [bom.metadata]
+    cpes = ["cpe:2.3:a:test:test-name:1.0.0:*:*:*:*:*:*:*"]
+    licenses = ["Apache-2.0"]
+    purl = "pkg:generic/test-name@1.0.0"
+    [bom.metadata.checksum]
+      algorithm = "SHA256"
+      hash = "test-sha256"
+`))
+		})
+
+		it("writes legacy build BOM", func() {
+			g.Expect(layers.Layers{Root: root}.WriteBuildMetadata(layers.BuildMetadata{
+				BOM: layers.BOM{{Name: "test-name", Version: "1.0.0"}},
+			})).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(root, "build.toml")).To(internal.HaveContent(`[[bom]]
+  name = "test-name"
+  version = "1.0.0"
+`))
+		})
+
+		it("writes legacy BOM versions in metadata for buildpack API 0.5 and later", func() {
+			g.Expect(layers.Layers{Root: root, API: version.MustParse("0.5")}.WriteBuildMetadata(layers.BuildMetadata{
+				BOM: layers.BOM{{Name: "test-name", Version: "1.0.0"}},
+			})).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(root, "build.toml")).To(internal.HaveContent(`[[bom]]
+  name = "test-name"
+  [bom.metadata]
+    version = "1.0.0"
+`))
+		})
+
+		it("filters entries by build and launch", func() {
+			bom := layers.BOM{
+				{Name: "test-build", Build: true},
+				{Name: "test-launch", Launch: true},
+				{Name: "test-both", Build: true, Launch: true},
+			}
+
+			g.Expect(bom.Build()).To(gomega.Equal(layers.BOM{bom[0], bom[2]}))
+			g.Expect(bom.Launch()).To(gomega.Equal(layers.BOM{bom[1], bom[2]}))
+		})
+	}, spec.Report(report.Terminal{}))
+}
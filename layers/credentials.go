@@ -0,0 +1,94 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var environmentVariableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CredentialVariable maps a credential of a bound service to an environment variable exported at launch.
+type CredentialVariable struct {
+	// Name is the name of the environment variable.
+	Name string
+
+	// Type is the type of the binding that contains the credential.  It is matched against the type file of Kubernetes
+	// bindings and the metadata/kind file of CNB bindings.
+	Type string
+
+	// Key is the key of the credential.  It names a file in the binding, so it must not contain a path separator.
+	Key string
+}
+
+// WriteCredentialProfile writes a profile.d script that exports credentials as environment variables at launch.  The
+// script reads the bindings in SERVICE_BINDING_ROOT and CNB_BINDINGS when the container starts so that only the mapping,
+// and never the credential itself, is stored in the layer.  If a credential is not bound, its variable is not set.
+func (l Layer) WriteCredentialProfile(file string, variables ...CredentialVariable) error {
+	var b strings.Builder
+
+	b.WriteString(`# Exports credentials from bindings at launch
+
+__libbuildpack_credential() {
+  for binding in "${SERVICE_BINDING_ROOT:-/dev/null}"/*; do
+    if [ "$(cat "$binding/type" 2>/dev/null)" = "$1" ] && [ -f "$binding/$2" ]; then
+      cat "$binding/$2"
+      return 0
+    fi
+  done
+
+  for binding in "${CNB_BINDINGS:-/dev/null}"/*; do
+    if [ "$(cat "$binding/metadata/kind" 2>/dev/null)" = "$1" ] && [ -f "$binding/secret/$2" ]; then
+      cat "$binding/secret/$2"
+      return 0
+    fi
+  done
+
+  return 1
+}
+`)
+
+	for _, v := range variables {
+		if !environmentVariableName.MatchString(v.Name) {
+			return fmt.Errorf("invalid environment variable name %q", v.Name)
+		}
+
+		if v.Key == "" || v.Key == "." || v.Key == ".." || strings.ContainsAny(v.Key, `/\`) {
+			return fmt.Errorf("invalid credential key %q", v.Key)
+		}
+
+		_, _ = fmt.Fprintf(&b, `
+if __libbuildpack_value="$(__libbuildpack_credential %s %s)"; then
+  export %s="$__libbuildpack_value"
+fi
+`, shellQuote(v.Type), shellQuote(v.Key), v.Name)
+	}
+
+	b.WriteString(`
+unset __libbuildpack_value
+unset -f __libbuildpack_credential
+`)
+
+	return l.WriteProfile(file, "%s", b.String())
+}
+
+// shellQuote quotes a string so that it is interpreted literally by a POSIX shell.
+func shellQuote(s string) string {
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `'\''`))
+}
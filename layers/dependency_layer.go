@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
+)
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// DependencyLayer is a layer that contains a dependency.  The dependency artifact is downloaded into, and reused from,
+// a DownloadLayer.
+type DependencyLayer struct {
+	Layer
+
+	// Dependency is the dependency contributed by the layer.
+	Dependency buildpack.Dependency
+
+	downloadLayer DownloadLayer
+}
+
+// Contribute contributes the dependency to the layer, reusing the existing contents if the dependency has not changed.
+// If the dependency has changed, the layer is removed and the contributor called with the path to the verified
+// dependency artifact.  An error is returned, before the layer is touched, if the dependency's ID or SHA256 cannot name
+// a layer.
+func (d DependencyLayer) Contribute(contributor DependencyLayerContributor, flags ...Flag) error {
+	if err := validateDependency(d.Dependency); err != nil {
+		return err
+	}
+
+	return d.Layer.Contribute(d.Dependency, func(layer Layer) error {
+		artifact, err := d.downloadLayer.Artifact()
+		if err != nil {
+			return err
+		}
+
+		return contributor(artifact, d)
+	}, flags...)
+}
+
+// DependencyLayerContributor is a function that contributes the contents of a dependency layer given the path to the
+// dependency artifact.
+type DependencyLayerContributor func(artifact string, layer DependencyLayer) error
+
+// validateDependency returns an error if the dependency's ID or SHA256 cannot be used as a layer name.  The ID must not
+// be empty or contain path elements and the SHA256 must be 64 lowercase hex characters.
+func validateDependency(dependency buildpack.Dependency) error {
+	if dependency.ID == "" || dependency.ID == "." || dependency.ID == ".." || strings.ContainsAny(dependency.ID, `/\`) {
+		return fmt.Errorf("invalid dependency ID %q", dependency.ID)
+	}
+
+	if !sha256Pattern.MatchString(dependency.SHA256) {
+		return fmt.Errorf("invalid SHA256 %q for dependency %s", dependency.SHA256, dependency.ID)
+	}
+
+	return nil
+}
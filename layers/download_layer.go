@@ -0,0 +1,244 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+)
+
+// DownloadLayer is a cache layer that contains a downloaded dependency artifact.  The layer is named after the SHA256
+// of the dependency so that the artifact is reused across builds for as long as the dependency does not change.
+//
+// Before downloading, the layer consults its dependency caches.  A dependency cache is a directory containing a
+// <sha256>.toml file with the dependency as its metadata and the artifact in a <sha256> directory, the same layout as
+// the download layers themselves.  Cached artifacts are only used if their SHA256 matches the dependency.  If the layer
+// is offline and the dependency is neither cached nor already in the layer, it fails rather than downloading.
+type DownloadLayer struct {
+	Layer
+
+	// Dependency is the dependency that is downloaded.
+	Dependency buildpack.Dependency
+
+	dependencyCaches []string
+	offline          bool
+}
+
+// Artifact returns the path to the dependency artifact, downloading and verifying it if it is not already cached.  An
+// error is returned, before any layer or cache is touched, if the dependency's ID or SHA256 cannot name a layer.
+func (d DownloadLayer) Artifact() (string, error) {
+	if err := validateDependency(d.Dependency); err != nil {
+		return "", err
+	}
+
+	artifact, ok, err := d.cachedArtifact()
+	if err != nil {
+		return "", err
+	}
+
+	if ok {
+		d.logger.Info("Using cached dependency from %s", artifact)
+		return artifact, nil
+	}
+
+	u, err := url.Parse(d.Dependency.URI)
+	if err != nil {
+		return "", err
+	}
+
+	artifact = filepath.Join(d.Root, path.Base(u.Path))
+
+	matches, err := d.Layer.MetadataMatches(d.Dependency)
+	if err != nil {
+		return "", err
+	}
+
+	if matches {
+		exists, err := internal.FileExists(artifact)
+		if err != nil {
+			return "", err
+		}
+
+		if !exists {
+			d.logger.Debug("Layer %s does not contain %s", d.Root, artifact)
+
+			if err := d.Layer.RemoveMetadata(); err != nil {
+				return "", err
+			}
+			matches = false
+		}
+	}
+
+	if !matches && d.offline {
+		if len(d.dependencyCaches) == 0 {
+			return "", fmt.Errorf("unable to download %s: offline and no dependency caches", d.Dependency.URI)
+		}
+
+		return "", fmt.Errorf("unable to download %s: offline and not cached in %s", d.Dependency.URI,
+			strings.Join(d.dependencyCaches, ", "))
+	}
+
+	if err := d.Layer.Contribute(d.Dependency, func(layer Layer) error {
+		return d.download(u, artifact)
+	}, Cache); err != nil {
+		return "", err
+	}
+
+	return artifact, nil
+}
+
+func (d DownloadLayer) cachedArtifact() (string, bool, error) {
+	for _, dir := range d.dependencyCaches {
+		l := Layer{
+			Root:     filepath.Join(dir, d.Dependency.SHA256),
+			Metadata: filepath.Join(dir, fmt.Sprintf("%s.toml", d.Dependency.SHA256)),
+			api:      d.api,
+			logger:   d.logger,
+		}
+
+		exists, err := internal.FileExists(l.Metadata)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !exists {
+			continue
+		}
+
+		var dependency buildpack.Dependency
+		if err := l.ReadMetadata(&dependency); err != nil {
+			return "", false, err
+		}
+
+		if dependency.SHA256 != d.Dependency.SHA256 {
+			d.logger.Debug("Dependency cache %s describes SHA256 %s, not %s", dir, dependency.SHA256, d.Dependency.SHA256)
+			continue
+		}
+
+		u, err := url.Parse(dependency.URI)
+		if err != nil {
+			return "", false, err
+		}
+
+		artifact := filepath.Join(l.Root, path.Base(u.Path))
+
+		exists, err = internal.FileExists(artifact)
+		if err != nil {
+			return "", false, err
+		}
+
+		if !exists {
+			d.logger.Debug("Dependency cache %s does not contain %s", dir, artifact)
+			continue
+		}
+
+		actual, err := sha256File(artifact)
+		if err != nil {
+			return "", false, err
+		}
+
+		if actual != d.Dependency.SHA256 {
+			d.logger.Info("Ignoring %s: SHA256 mismatch, expected %s, actual %s", artifact, d.Dependency.SHA256, actual)
+			continue
+		}
+
+		return artifact, true, nil
+	}
+
+	return "", false, nil
+}
+
+func sha256File(file string) (string, error) {
+	in, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	s := sha256.New()
+	if _, err := io.Copy(s, in); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(s.Sum(nil)), nil
+}
+
+func (d DownloadLayer) download(u *url.URL, artifact string) error {
+	d.logger.Info("Downloading from %s", d.Dependency.URI)
+
+	in, err := d.open(u)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := ioutil.TempFile(d.Root, "download")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(out.Name())
+	defer out.Close()
+
+	s := sha256.New()
+	if _, err := io.Copy(out, io.TeeReader(in, s)); err != nil {
+		return fmt.Errorf("unable to download %s: %w", d.Dependency.URI, err)
+	}
+
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	d.logger.Info("Verifying checksum")
+	if actual := hex.EncodeToString(s.Sum(nil)); actual != d.Dependency.SHA256 {
+		return fmt.Errorf("SHA256 mismatch for %s: expected %s, actual %s", d.Dependency.URI, d.Dependency.SHA256, actual)
+	}
+
+	return os.Rename(out.Name(), artifact)
+}
+
+func (d DownloadLayer) open(u *url.URL) (io.ReadCloser, error) {
+	switch u.Scheme {
+	case "file":
+		return os.Open(u.Path)
+	case "http", "https":
+		resp, err := http.Get(u.String())
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("unable to download %s: %s", d.Dependency.URI, resp.Status)
+		}
+
+		return resp.Body, nil
+	default:
+		return nil, fmt.Errorf("unsupported URI scheme %q in %s", u.Scheme, d.Dependency.URI)
+	}
+}
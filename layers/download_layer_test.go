@@ -0,0 +1,311 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+// SHA256 of "test-content"
+const (
+	testSHA256  = "0a3666a0710c08aa6d0de92ce72beeb5b93124cce1bf3701c9d6cdeb543cb73e"
+	otherSHA256 = "1111111111111111111111111111111111111111111111111111111111111111"
+)
+
+func TestDownloadLayer(t *testing.T) {
+	spec.Run(t, "DownloadLayer", func(t *testing.T, when spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		var (
+			requests int
+			root     string
+			server   *httptest.Server
+		)
+
+		it.Before(func() {
+			requests = 0
+			root = internal.ScratchDir(t, "download-layer")
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				if r.URL.Path != "/test-path/test-artifact.tgz" {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+
+				_, _ = fmt.Fprint(w, "test-content")
+			}))
+		})
+
+		it.After(func() {
+			server.Close()
+		})
+
+		it("downloads and caches an artifact over HTTP", func() {
+			dependency := buildpack.Dependency{
+				ID:     "test-id",
+				URI:    fmt.Sprintf("%s/test-path/test-artifact.tgz", server.URL),
+				SHA256: testSHA256,
+			}
+
+			artifact, err := layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(artifact).To(gomega.Equal(filepath.Join(root, testSHA256, "test-artifact.tgz")))
+			g.Expect(artifact).To(internal.HaveContent("test-content"))
+			g.Expect(filepath.Join(root, fmt.Sprintf("%s.toml", testSHA256))).To(gomega.BeARegularFile())
+			g.Expect(requests).To(gomega.Equal(1))
+		})
+
+		it("reuses a cached artifact", func() {
+			dependency := buildpack.Dependency{
+				ID:     "test-id",
+				URI:    fmt.Sprintf("%s/test-path/test-artifact.tgz", server.URL),
+				SHA256: testSHA256,
+			}
+
+			_, err := layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			_, err = layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(requests).To(gomega.Equal(1))
+		})
+
+		it("downloads a file URI", func() {
+			internal.WriteTestFile(t, filepath.Join(root, "source", "test-artifact.tgz"), "test-content")
+
+			dependency := buildpack.Dependency{
+				ID:     "test-id",
+				URI:    fmt.Sprintf("file://%s", filepath.Join(root, "source", "test-artifact.tgz")),
+				SHA256: testSHA256,
+			}
+
+			artifact, err := layers.Layers{Root: filepath.Join(root, "layers")}.DownloadLayer(dependency).Artifact()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(artifact).To(internal.HaveContent("test-content"))
+		})
+
+		it("returns an error when the checksum does not match", func() {
+			dependency := buildpack.Dependency{
+				ID:     "test-id",
+				URI:    fmt.Sprintf("%s/test-path/test-artifact.tgz", server.URL),
+				SHA256: otherSHA256,
+			}
+
+			_, err := layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+			g.Expect(err).To(gomega.MatchError(gomega.HavePrefix("SHA256 mismatch")))
+
+			g.Expect(filepath.Join(root, otherSHA256, "test-artifact.tgz")).NotTo(gomega.BeAnExistingFile())
+			g.Expect(filepath.Join(root, fmt.Sprintf("%s.toml", otherSHA256))).NotTo(gomega.BeAnExistingFile())
+		})
+
+		it("returns an error when the download fails", func() {
+			dependency := buildpack.Dependency{
+				ID:     "test-id",
+				URI:    fmt.Sprintf("%s/other-path/test-artifact.tgz", server.URL),
+				SHA256: testSHA256,
+			}
+
+			_, err := layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+			g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("404 Not Found")))
+		})
+
+		it("rejects dependencies that cannot name a layer", func() {
+			internal.WriteTestFile(t, filepath.Join(root, "other-layer", "test-file"), "test-content")
+
+			for _, c := range []struct {
+				dependency buildpack.Dependency
+				message    string
+			}{
+				{buildpack.Dependency{ID: "test-id"}, `invalid SHA256 "" for dependency test-id`},
+				{buildpack.Dependency{ID: "test-id", SHA256: "../other-layer"}, `invalid SHA256 "../other-layer" for dependency test-id`},
+				{buildpack.Dependency{SHA256: testSHA256}, `invalid dependency ID ""`},
+				{buildpack.Dependency{ID: "../other-layer", SHA256: testSHA256}, `invalid dependency ID "../other-layer"`},
+			} {
+				c.dependency.URI = fmt.Sprintf("%s/test-path/test-artifact.tgz", server.URL)
+				l := layers.Layers{Root: root}
+
+				_, err := l.DownloadLayer(c.dependency).Artifact()
+				g.Expect(err).To(gomega.MatchError(c.message))
+
+				g.Expect(l.DependencyLayer(c.dependency).Contribute(func(artifact string, layer layers.DependencyLayer) error {
+					return nil
+				})).To(gomega.MatchError(c.message))
+			}
+
+			g.Expect(filepath.Join(root, "other-layer", "test-file")).To(gomega.BeARegularFile())
+			g.Expect(requests).To(gomega.Equal(0))
+		})
+
+		when("dependency caches", func() {
+
+			var dependency buildpack.Dependency
+
+			it.Before(func() {
+				dependency = buildpack.Dependency{
+					ID:     "test-id",
+					URI:    fmt.Sprintf("%s/test-path/test-artifact.tgz", server.URL),
+					SHA256: testSHA256,
+				}
+			})
+
+			it("uses an artifact from a dependency cache", func() {
+				cache := filepath.Join(root, "cache")
+				internal.WriteTestFile(t, filepath.Join(cache, fmt.Sprintf("%s.toml", testSHA256)), `[metadata]
+id = "test-id"
+uri = "https://localhost/test-path/test-artifact.tgz"
+sha256 = "%s"
+`, testSHA256)
+				internal.WriteTestFile(t, filepath.Join(cache, testSHA256, "test-artifact.tgz"), "test-content")
+
+				l := layers.Layers{Root: filepath.Join(root, "layers"), DependencyCaches: []string{filepath.Join(root, "empty"), cache}, Offline: true}
+
+				artifact, err := l.DownloadLayer(dependency).Artifact()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				g.Expect(artifact).To(gomega.Equal(filepath.Join(cache, testSHA256, "test-artifact.tgz")))
+				g.Expect(requests).To(gomega.Equal(0))
+			})
+
+			it("ignores a corrupt artifact in a dependency cache", func() {
+				cache := filepath.Join(root, "cache")
+				internal.WriteTestFile(t, filepath.Join(cache, fmt.Sprintf("%s.toml", testSHA256)), `[metadata]
+id = "test-id"
+uri = "https://localhost/test-path/test-artifact.tgz"
+sha256 = "%s"
+`, testSHA256)
+				internal.WriteTestFile(t, filepath.Join(cache, testSHA256, "test-artifact.tgz"), "corrupt-content")
+
+				l := layers.Layers{Root: filepath.Join(root, "layers"), DependencyCaches: []string{cache}, Offline: true}
+
+				_, err := l.DownloadLayer(dependency).Artifact()
+				g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("offline and not cached")))
+
+				l.Offline = false
+
+				artifact, err := l.DownloadLayer(dependency).Artifact()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				g.Expect(artifact).To(gomega.Equal(filepath.Join(root, "layers", testSHA256, "test-artifact.tgz")))
+				g.Expect(artifact).To(internal.HaveContent("test-content"))
+				g.Expect(requests).To(gomega.Equal(1))
+			})
+
+			it("ignores a dependency cache describing a different SHA256", func() {
+				cache := filepath.Join(root, "cache")
+				internal.WriteTestFile(t, filepath.Join(cache, fmt.Sprintf("%s.toml", testSHA256)), `[metadata]
+id = "test-id"
+uri = "https://localhost/test-path/test-artifact.tgz"
+sha256 = "%s"
+`, otherSHA256)
+				internal.WriteTestFile(t, filepath.Join(cache, testSHA256, "test-artifact.tgz"), "test-content")
+
+				l := layers.Layers{Root: filepath.Join(root, "layers"), DependencyCaches: []string{cache}, Offline: true}
+
+				_, err := l.DownloadLayer(dependency).Artifact()
+				g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("offline and not cached")))
+			})
+
+			it("downloads when not cached and online", func() {
+				l := layers.Layers{Root: root, DependencyCaches: []string{filepath.Join(root, "empty")}}
+
+				_, err := l.DownloadLayer(dependency).Artifact()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				g.Expect(requests).To(gomega.Equal(1))
+			})
+
+			it("returns an error when not cached and offline", func() {
+				l := layers.Layers{Root: root, DependencyCaches: []string{filepath.Join(root, "empty")}, Offline: true}
+
+				_, err := l.DownloadLayer(dependency).Artifact()
+				g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("offline and not cached")))
+
+				g.Expect(requests).To(gomega.Equal(0))
+			})
+
+			it("returns an error when offline without dependency caches", func() {
+				l := layers.Layers{Root: root, Offline: true}
+
+				_, err := l.DownloadLayer(dependency).Artifact()
+				g.Expect(err).To(gomega.MatchError(fmt.Sprintf("unable to download %s: offline and no dependency caches", dependency.URI)))
+			})
+
+			it("reuses an artifact already in the layer when offline", func() {
+				_, err := layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				artifact, err := layers.Layers{Root: root, Offline: true}.DownloadLayer(dependency).Artifact()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				g.Expect(artifact).To(internal.HaveContent("test-content"))
+				g.Expect(requests).To(gomega.Equal(1))
+			})
+
+			it("downloads again when the layer metadata matches but the artifact is missing", func() {
+				_, err := layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+				g.Expect(os.Remove(filepath.Join(root, testSHA256, "test-artifact.tgz"))).To(gomega.Succeed())
+
+				artifact, err := layers.Layers{Root: root}.DownloadLayer(dependency).Artifact()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				g.Expect(artifact).To(internal.HaveContent("test-content"))
+				g.Expect(requests).To(gomega.Equal(2))
+			})
+		})
+
+		when("dependency layer", func() {
+
+			it("contributes the downloaded artifact to the layer", func() {
+				dependency := buildpack.Dependency{
+					ID:     "test-id",
+					URI:    fmt.Sprintf("%s/test-path/test-artifact.tgz", server.URL),
+					SHA256: testSHA256,
+				}
+
+				layer := layers.Layers{Root: root}.DependencyLayer(dependency)
+
+				var actual string
+				g.Expect(layer.Contribute(func(artifact string, layer layers.DependencyLayer) error {
+					actual = artifact
+					return nil
+				}, layers.Launch)).To(gomega.Succeed())
+
+				g.Expect(actual).To(gomega.Equal(filepath.Join(root, testSHA256, "test-artifact.tgz")))
+				g.Expect(filepath.Join(root, "test-id")).To(gomega.BeADirectory())
+				g.Expect(filepath.Join(root, "test-id.toml")).To(gomega.BeARegularFile())
+			})
+		})
+	}, spec.Report(report.Terminal{}))
+}
@@ -0,0 +1,236 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+)
+
+// Scope is the phase that an environment variable applies to.  Its value is the name of the layer directory that
+// contains the variable.
+type Scope string
+
+const (
+	// ScopeShared indicates that an environment variable applies at both build and launch.
+	ScopeShared Scope = "env"
+
+	// ScopeBuild indicates that an environment variable applies at build.
+	ScopeBuild Scope = "env.build"
+
+	// ScopeLaunch indicates that an environment variable applies at launch.
+	ScopeLaunch Scope = "env.launch"
+)
+
+// Action is the way an environment variable is combined with previous declarations of the same variable.
+type Action string
+
+const (
+	// ActionAppend appends the value to previous declarations.
+	ActionAppend Action = "append"
+
+	// ActionDefault sets the value if there are no previous declarations.
+	ActionDefault Action = "default"
+
+	// ActionOverride replaces previous declarations with the value.
+	ActionOverride Action = "override"
+
+	// ActionPrepend prepends the value to previous declarations.
+	ActionPrepend Action = "prepend"
+
+	// ActionPrependPath prepends the value to previous declarations using the OS path delimiter.
+	ActionPrependPath Action = "prepend-path"
+
+	// delimiter is the suffix of files that declare a delimiter rather than a value.
+	delimiter Action = "delim"
+)
+
+// EnvironmentVariable is an environment variable contributed by a layer.
+type EnvironmentVariable struct {
+	// Name is the name of the environment variable.
+	Name string
+
+	// Action is the way the value is combined with previous declarations.
+	Action Action
+
+	// Value is the value of the environment variable.
+	Value string
+
+	// Delimiter is the delimiter used when combining the value with previous declarations.  Optional.
+	Delimiter string
+
+	// Scope is the phase the environment variable applies to.
+	Scope Scope
+
+	// Process is the process type the environment variable applies to.  Only launch variables may be limited to a
+	// process type.  Optional.
+	Process string
+}
+
+// LayerEnvironment is the collection of environment variables contributed by a layer.
+type LayerEnvironment []EnvironmentVariable
+
+// Scope returns the environment variables that apply to a scope.
+func (l LayerEnvironment) Scope(scope Scope) LayerEnvironment {
+	var filtered LayerEnvironment
+
+	for _, e := range l {
+		if e.Scope == scope {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// Process returns the environment variables that apply to a process type.  Variables without a process type are not
+// included.
+func (l LayerEnvironment) Process(process string) LayerEnvironment {
+	var filtered LayerEnvironment
+
+	for _, e := range l {
+		if e.Process != "" && e.Process == process {
+			filtered = append(filtered, e)
+		}
+	}
+
+	return filtered
+}
+
+// Environment reads the environment variables contributed by the layer in its env, env.build, and env.launch
+// directories, and the process-specific env.launch/<process> directories.  Variables are returned in scope order, with
+// process-specific variables after the other launch variables, and then in name order.  Files without an action suffix
+// prepend using the OS path delimiter before buildpack API 0.5 and override from buildpack API 0.5.
+func (l Layer) Environment() (LayerEnvironment, error) {
+	var environment LayerEnvironment
+
+	action := ActionOverride
+	if l.api.LessThan(overrideEnvAPI) {
+		action = ActionPrependPath
+	}
+
+	for _, s := range []Scope{ScopeShared, ScopeBuild, ScopeLaunch} {
+		e, err := readEnvironment(filepath.Join(l.Root, string(s)), s, "", action)
+		if err != nil {
+			return nil, err
+		}
+
+		environment = append(environment, e...)
+	}
+
+	processes, err := readDirectories(filepath.Join(l.Root, string(ScopeLaunch)))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range processes {
+		e, err := readEnvironment(filepath.Join(l.Root, string(ScopeLaunch), p), ScopeLaunch, p, action)
+		if err != nil {
+			return nil, err
+		}
+
+		environment = append(environment, e...)
+	}
+
+	return environment, nil
+}
+
+func readDirectories(dir string) ([]string, error) {
+	if exists, err := internal.FileExists(dir); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var directories []string
+	for _, f := range files {
+		if f.IsDir() {
+			directories = append(directories, f.Name())
+		}
+	}
+
+	return directories, nil
+}
+
+func readEnvironment(dir string, scope Scope, process string, unsuffixed Action) (LayerEnvironment, error) {
+	if exists, err := internal.FileExists(dir); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, nil
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	delimiters := make(map[string]string)
+	var environment LayerEnvironment
+
+	for _, f := range files {
+		if f.IsDir() {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		name, action := parseEnvironmentFile(f.Name(), unsuffixed)
+		if action == delimiter {
+			delimiters[name] = string(b)
+			continue
+		}
+
+		environment = append(environment, EnvironmentVariable{
+			Name:    name,
+			Action:  action,
+			Value:   string(b),
+			Scope:   scope,
+			Process: process,
+		})
+	}
+
+	for i, e := range environment {
+		environment[i].Delimiter = delimiters[e.Name]
+	}
+
+	return environment, nil
+}
+
+func parseEnvironmentFile(file string, unsuffixed Action) (string, Action) {
+	i := strings.LastIndex(file, ".")
+	if i < 0 {
+		return file, unsuffixed
+	}
+
+	switch a := Action(file[i+1:]); a {
+	case ActionAppend, ActionDefault, ActionOverride, ActionPrepend, delimiter:
+		return file[:i], a
+	default:
+		return file, unsuffixed
+	}
+}
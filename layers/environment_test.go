@@ -0,0 +1,84 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/buildpacks/libbuildpack/v2/version"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestEnvironment(t *testing.T) {
+	spec.Run(t, "Environment", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		var layer layers.Layer
+
+		it.Before(func() {
+			layer = layers.Layers{Root: internal.ScratchDir(t, "environment")}.Layer("test-layer")
+		})
+
+		it("reads the environment contributed by the layer", func() {
+			g.Expect(layer.AppendSharedEnv("TEST_APPEND", "test-append")).To(gomega.Succeed())
+			g.Expect(layer.DelimiterSharedEnv("TEST_APPEND", ":")).To(gomega.Succeed())
+			g.Expect(layer.DefaultBuildEnv("TEST_DEFAULT", "test-default")).To(gomega.Succeed())
+			g.Expect(layer.OverrideLaunchEnv("TEST_OVERRIDE", "test-override")).To(gomega.Succeed())
+			g.Expect(layer.PrependLaunchEnv("TEST_PREPEND", "test-prepend")).To(gomega.Succeed())
+			g.Expect(layer.PrependPathLaunchEnv("PATH", "test-path")).To(gomega.Succeed())
+
+			e, err := layer.Environment()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(e).To(gomega.Equal(layers.LayerEnvironment{
+				{Name: "TEST_APPEND", Action: layers.ActionAppend, Value: "test-append", Delimiter: ":", Scope: layers.ScopeShared},
+				{Name: "TEST_DEFAULT", Action: layers.ActionDefault, Value: "test-default", Scope: layers.ScopeBuild},
+				{Name: "PATH", Action: layers.ActionPrependPath, Value: "test-path", Scope: layers.ScopeLaunch},
+				{Name: "TEST_OVERRIDE", Action: layers.ActionOverride, Value: "test-override", Scope: layers.ScopeLaunch},
+				{Name: "TEST_PREPEND", Action: layers.ActionPrepend, Value: "test-prepend", Scope: layers.ScopeLaunch},
+			}))
+
+			g.Expect(e.Scope(layers.ScopeBuild)).To(gomega.Equal(layers.LayerEnvironment{
+				{Name: "TEST_DEFAULT", Action: layers.ActionDefault, Value: "test-default", Scope: layers.ScopeBuild},
+			}))
+		})
+
+		it("overrides with files without a suffix from buildpack API 0.5", func() {
+			layer = layers.Layers{Root: internal.ScratchDir(t, "environment"), API: version.MustParse("0.5")}.Layer("test-layer")
+
+			g.Expect(internal.WriteFile(filepath.Join(layer.Root, "env.launch", "TEST_OVERRIDE"), 0644, "test-override")).
+				To(gomega.Succeed())
+			g.Expect(layer.PrependPathLaunchEnv("PATH", "test-path")).To(gomega.Succeed())
+
+			g.Expect(layer.Environment()).To(gomega.Equal(layers.LayerEnvironment{
+				{Name: "PATH", Action: layers.ActionPrepend, Value: "test-path", Delimiter: string(os.PathListSeparator), Scope: layers.ScopeLaunch},
+				{Name: "TEST_OVERRIDE", Action: layers.ActionOverride, Value: "test-override", Scope: layers.ScopeLaunch},
+			}))
+		})
+
+		it("is empty when the layer has no environment", func() {
+			g.Expect(layer.Environment()).To(gomega.BeEmpty())
+		})
+	}, spec.Report(report.Terminal{}))
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+var execDAPI = version.MustParse("0.5")
+
+// WriteExecD installs a program into the layer's exec.d directory.  At launch, the lifecycle executes exec.d programs
+// in lexical order and adds the environment variables they print (see the execd package) to the process environment.
+// The program is installed as <order>-<name>, with order zero-padded to two digits so that programs run in ascending
+// order.  order must be between 0 and 99 and name must not contain path separators.  exec.d requires buildpack API 0.5
+// or later.
+func (l Layer) WriteExecD(order int, name string, program string) error {
+	if l.api.LessThan(execDAPI) {
+		return fmt.Errorf("exec.d requires buildpack API %s or later, buildpack API is %s", execDAPI, l.api)
+	}
+
+	if order < 0 || order > 99 {
+		return fmt.Errorf("exec.d order must be between 0 and 99: %d", order)
+	}
+
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid exec.d program name %q", name)
+	}
+
+	f := filepath.Join(l.Root, "exec.d", fmt.Sprintf("%02d-%s", order, name))
+	l.logger.Debug("Writing exec.d program: %s <= %s", f, program)
+
+	in, err := os.Open(program)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(f, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0755)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, in); err != nil {
+		return err
+	}
+
+	return os.Chmod(f, 0755)
+}
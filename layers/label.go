@@ -0,0 +1,39 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+// Labels is a collection of Label instances.
+type Labels []Label
+
+// Label represents an image label.
+type Label struct {
+	// Key is the key of the label.
+	Key string `toml:"key"`
+
+	// Value is the value of the label.
+	Value string `toml:"value"`
+}
+
+func (l Labels) contains(key string) bool {
+	for _, label := range l {
+		if label.Key == key {
+			return true
+		}
+	}
+
+	return false
+}
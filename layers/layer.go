@@ -17,13 +17,25 @@
 package layers
 
 import (
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+var (
+	layerTypesAPI  = version.MustParse("0.6")
+	overrideEnvAPI = version.MustParse("0.5")
+
+	processType = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
 )
 
 // Layer represents a layer for an application.
@@ -34,6 +46,7 @@ type Layer struct {
 	// Metadata is the location of the layer's metadata file.
 	Metadata string
 
+	api    version.Version
 	logger logger.Logger
 }
 
@@ -55,6 +68,48 @@ func (l Layer) AppendSharedEnv(name string, format string, args ...interface{})
 	return l.addSharedEnvFile(fmt.Sprintf("%s.append", name), format, args...)
 }
 
+// AppendProcessEnv appends the value of this environment variable to any previous declarations of the value without
+// any delimitation, for a process type only.  If delimitation is important during concatenation, callers are required
+// to add it.
+func (l Layer) AppendProcessEnv(process string, name string, format string, args ...interface{}) error {
+	return l.addProcessEnvFile(process, fmt.Sprintf("%s.append", name), format, args...)
+}
+
+// Contribute contributes the contents of the layer, reusing the existing contents if the layer's metadata matches the
+// expected metadata.  If the metadata does not match, the layer and its metadata are removed, recreated by the
+// contributor, and the expected metadata written along with the flags.
+func (l Layer) Contribute(expected interface{}, contributor LayerContributor, flags ...Flag) error {
+	matches, err := l.MetadataMatches(expected)
+	if err != nil {
+		return err
+	}
+
+	if matches {
+		l.logger.Info("Reusing cached layer %s", l.Root)
+		return l.WriteMetadata(expected, flags...)
+	}
+
+	l.logger.Info("Contributing to layer %s", l.Root)
+
+	if err := l.RemoveMetadata(); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(l.Root); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(l.Root, 0755); err != nil {
+		return err
+	}
+
+	if err := contributor(l); err != nil {
+		return err
+	}
+
+	return l.WriteMetadata(expected, flags...)
+}
+
 // DefaultBuildEnv sets a default for an environment variable with this value.
 func (l Layer) DefaultBuildEnv(name string, format string, args ...interface{}) error {
 	return l.addBuildEnvFile(fmt.Sprintf("%s.default", name), format, args...)
@@ -65,6 +120,11 @@ func (l Layer) DefaultLaunchEnv(name string, format string, args ...interface{})
 	return l.addLaunchEnvFile(fmt.Sprintf("%s.default", name), format, args...)
 }
 
+// DefaultProcessEnv sets a default for an environment variable with this value, for a process type only.
+func (l Layer) DefaultProcessEnv(process string, name string, format string, args ...interface{}) error {
+	return l.addProcessEnvFile(process, fmt.Sprintf("%s.default", name), format, args...)
+}
+
 // DefaultSharedEnv sets a default for an environment variable with this value.
 func (l Layer) DefaultSharedEnv(name string, format string, args ...interface{}) error {
 	return l.addSharedEnvFile(fmt.Sprintf("%s.default", name), format, args...)
@@ -80,11 +140,45 @@ func (l Layer) DelimiterLaunchEnv(name string, delimiter string) error {
 	return l.addLaunchEnvFile(fmt.Sprintf("%s.delim", name), delimiter)
 }
 
+// DelimiterProcessEnv sets a delimiter for an environment variable with this value, for a process type only.
+func (l Layer) DelimiterProcessEnv(process string, name string, delimiter string) error {
+	return l.addProcessEnvFile(process, fmt.Sprintf("%s.delim", name), delimiter)
+}
+
 // DelimiterSharedEnv sets a delimiter for an environment variable with this value.
 func (l Layer) DelimiterSharedEnv(name string, delimiter string) error {
 	return l.addSharedEnvFile(fmt.Sprintf("%s.delim", name), delimiter)
 }
 
+// MetadataMatches compares the expected metadata for deep equality with the layer's existing metadata.  Both values are
+// compared in their TOML representation, so that a struct and the map it was written from are considered equal.
+func (l Layer) MetadataMatches(expected interface{}) (bool, error) {
+	exists, err := internal.FileExists(l.Metadata)
+	if err != nil {
+		return false, err
+	}
+
+	if !exists {
+		l.logger.Debug("Metadata %s does not exist", l.Metadata)
+		return false, nil
+	}
+
+	var actual map[string]interface{}
+	if err := l.ReadMetadata(&actual); err != nil {
+		return false, err
+	}
+
+	e, err := normalizeMetadata(expected)
+	if err != nil {
+		return false, err
+	}
+
+	matches := reflect.DeepEqual(e, actual)
+	l.logger.Debug("Layer metadata matches: %t, expected %v, actual %v", matches, e, actual)
+
+	return matches, nil
+}
+
 // OverrideBuildEnv overrides any existing value for an environment variable with this value.
 func (l Layer) OverrideBuildEnv(name string, format string, args ...interface{}) error {
 	return l.addBuildEnvFile(fmt.Sprintf("%s.override", name), format, args...)
@@ -95,6 +189,11 @@ func (l Layer) OverrideLaunchEnv(name string, format string, args ...interface{}
 	return l.addLaunchEnvFile(fmt.Sprintf("%s.override", name), format, args...)
 }
 
+// OverrideProcessEnv overrides any existing value for an environment variable with this value, for a process type only.
+func (l Layer) OverrideProcessEnv(process string, name string, format string, args ...interface{}) error {
+	return l.addProcessEnvFile(process, fmt.Sprintf("%s.override", name), format, args...)
+}
+
 // OverrideSharedEnv overrides any existing value for an environment variable with this value.
 func (l Layer) OverrideSharedEnv(name string, format string, args ...interface{}) error {
 	return l.addSharedEnvFile(fmt.Sprintf("%s.override", name), format, args...)
@@ -112,6 +211,13 @@ func (l Layer) PrependLaunchEnv(name string, format string, args ...interface{})
 	return l.addLaunchEnvFile(fmt.Sprintf("%s.prepend", name), format, args...)
 }
 
+// PrependProcessEnv prepends the value of this environment variable to any previous declarations of the value without
+// any delimitation, for a process type only.  If delimitation is important during concatenation, callers are required
+// to add it.
+func (l Layer) PrependProcessEnv(process string, name string, format string, args ...interface{}) error {
+	return l.addProcessEnvFile(process, fmt.Sprintf("%s.prepend", name), format, args...)
+}
+
 // PrependSharedEnv prepends the value of this environment variable to any previous declarations of the value without
 // any delimitation.  If delimitation is important during concatenation, callers are required to add it.
 func (l Layer) PrependSharedEnv(name string, format string, args ...interface{}) error {
@@ -121,19 +227,27 @@ func (l Layer) PrependSharedEnv(name string, format string, args ...interface{})
 // PrependPathBuildEnv prepends the value of this environment variable to any previous declarations of the value using
 // the OS path delimiter.
 func (l Layer) PrependPathBuildEnv(name string, format string, args ...interface{}) error {
-	return l.addBuildEnvFile(name, format, args...)
+	return l.addPrependPathEnvFile(l.addBuildEnvFile, name, format, args...)
 }
 
 // PrependPathLaunchEnv prepends the value of this environment variable to any previous declarations of the value using
 // the OS path delimiter.
 func (l Layer) PrependPathLaunchEnv(name string, format string, args ...interface{}) error {
-	return l.addLaunchEnvFile(name, format, args...)
+	return l.addPrependPathEnvFile(l.addLaunchEnvFile, name, format, args...)
+}
+
+// PrependPathProcessEnv prepends the value of this environment variable to any previous declarations of the value
+// using the OS path delimiter, for a process type only.
+func (l Layer) PrependPathProcessEnv(process string, name string, format string, args ...interface{}) error {
+	return l.addPrependPathEnvFile(func(file string, format string, args ...interface{}) error {
+		return l.addProcessEnvFile(process, file, format, args...)
+	}, name, format, args...)
 }
 
 // PrependPathSharedEnv prepends the value of this environment variable to any previous declarations of the value using
 // the OS path delimiter.
 func (l Layer) PrependPathSharedEnv(name string, format string, args ...interface{}) error {
-	return l.addSharedEnvFile(name, format, args...)
+	return l.addPrependPathEnvFile(l.addSharedEnvFile, name, format, args...)
 }
 
 // ReadMetadata reads arbitrary layer metadata from the filesystem.
@@ -180,25 +294,50 @@ func (l Layer) RemoveMetadata() error {
 	return os.Remove(l.Metadata)
 }
 
-// WriteMetadata writes arbitrary layer metadata to the filesystem.
+// WriteMetadata writes arbitrary layer metadata to the filesystem.  From buildpack API 0.6 the flags are written in a
+// [types] table, otherwise they are written as top-level keys.
 func (l Layer) WriteMetadata(metadata interface{}, flags ...Flag) error {
-	lm := layerMetadata{Metadata: metadata}
+	var t layerTypes
 
 	for _, flag := range flags {
 		switch flag {
 		case Build:
-			lm.Build = true
+			t.Build = true
 		case Cache:
-			lm.Cache = true
+			t.Cache = true
 		case Launch:
-			lm.Launch = true
+			t.Launch = true
 		}
 	}
 
+	if l.api.LessThan(layerTypesAPI) {
+		lm := layerMetadata{t.Build, t.Cache, t.Launch, metadata}
+
+		l.logger.Debug("Writing layer metadata: %s <= %#v", l.Metadata, lm)
+		return internal.WriteTomlFile(l.Metadata, 0644, lm)
+	}
+
+	lm := layerTypesMetadata{t, metadata}
+
 	l.logger.Debug("Writing layer metadata: %s <= %#v", l.Metadata, lm)
 	return internal.WriteTomlFile(l.Metadata, 0644, lm)
 }
 
+// WriteBOM writes the layer's bill of materials as <layer>.sbom.cdx.json (CycloneDX) and <layer>.sbom.spdx.json (SPDX)
+// files alongside the layer's metadata.  Before buildpack API 0.7 the lifecycle does not read these files, and nothing
+// is written.
+func (l Layer) WriteBOM(bom BOM) error {
+	if l.api.LessThan(sbomAPI) {
+		l.logger.Debug("Not writing layer BOM, requires buildpack API %s or later, buildpack API is %s", sbomAPI, l.api)
+		return nil
+	}
+
+	prefix := strings.TrimSuffix(l.Metadata, ".toml")
+
+	l.logger.Debug("Writing layer BOM: %s.sbom.* <= %v", prefix, bom)
+	return writeSBOM(prefix, filepath.Base(l.Root), bom)
+}
+
 // WriteProfile writes a file to profile.d with this value.
 func (l Layer) WriteProfile(file string, format string, args ...interface{}) error {
 	f := filepath.Join(l.Root, "profile.d", file)
@@ -228,13 +367,69 @@ func (l Layer) addLaunchEnvFile(file string, format string, args ...interface{})
 	return l.addEnvFile(filepath.Join("env.launch", file), format, args...)
 }
 
+// addPrependPathEnvFile writes a prepend path environment variable.  Before buildpack API 0.5 a file without a suffix
+// is prepended using the OS path delimiter.  From buildpack API 0.5 a file without a suffix overrides, so a prepend file
+// and a delimiter file are written instead.
+func (l Layer) addPrependPathEnvFile(add func(file string, format string, args ...interface{}) error,
+	name string, format string, args ...interface{}) error {
+
+	if l.api.LessThan(overrideEnvAPI) {
+		return add(name, format, args...)
+	}
+
+	if err := add(fmt.Sprintf("%s.prepend", name), format, args...); err != nil {
+		return err
+	}
+
+	return add(fmt.Sprintf("%s.delim", name), string(os.PathListSeparator))
+}
+
+func (l Layer) addProcessEnvFile(process string, file string, format string, args ...interface{}) error {
+	if !processType.MatchString(process) || process == "." || process == ".." {
+		return fmt.Errorf("invalid process type %q", process)
+	}
+
+	return l.addEnvFile(filepath.Join("env.launch", process, file), format, args...)
+}
+
 func (l Layer) addSharedEnvFile(file string, format string, args ...interface{}) error {
 	return l.addEnvFile(filepath.Join("env", file), format, args...)
 }
 
+func normalizeMetadata(metadata interface{}) (map[string]interface{}, error) {
+	b := &bytes.Buffer{}
+	if err := toml.NewEncoder(b).Encode(layerMetadata{Metadata: metadata}); err != nil {
+		return nil, err
+	}
+
+	var out struct {
+		Metadata map[string]interface{} `toml:"metadata"`
+	}
+
+	if _, err := toml.Decode(b.String(), &out); err != nil {
+		return nil, err
+	}
+
+	return out.Metadata, nil
+}
+
+// LayerContributor is a function that contributes the contents of a layer.
+type LayerContributor func(layer Layer) error
+
 type layerMetadata struct {
 	Build    bool        `toml:"build"`
 	Cache    bool        `toml:"cache"`
 	Launch   bool        `toml:"launch"`
 	Metadata interface{} `toml:"metadata"`
 }
+
+type layerTypes struct {
+	Build  bool `toml:"build"`
+	Cache  bool `toml:"cache"`
+	Launch bool `toml:"launch"`
+}
+
+type layerTypesMetadata struct {
+	Types    layerTypes  `toml:"types"`
+	Metadata interface{} `toml:"metadata"`
+}
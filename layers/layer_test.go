@@ -17,11 +17,16 @@
 package layers_test
 
 import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/buildpacks/libbuildpack/v2/version"
 	"github.com/onsi/gomega"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -92,11 +97,267 @@ launch = true
 `))
 			})
 
+			it("writes layer content metadata with a types table for buildpack API 0.6 and later", func() {
+				layer = layers.Layers{Root: root, API: version.MustParse("0.6")}.Layer("test-layer")
+
+				g.Expect(layer.WriteMetadata(metadata{"test-value", 1}, layers.Launch)).To(gomega.Succeed())
+
+				g.Expect(filepath.Join(root, "test-layer.toml")).To(internal.HaveContent(`[types]
+  build = false
+  cache = false
+  launch = true
+
+[metadata]
+  Alpha = "test-value"
+  Bravo = 1
+`))
+			})
+
+			it("writes process-specific environment variables", func() {
+				g.Expect(layer.AppendProcessEnv("web", "TEST_APPEND", "%s-%d", "test-string", 1)).To(gomega.Succeed())
+				g.Expect(layer.DefaultProcessEnv("web", "TEST_DEFAULT", "%s-%d", "test-string", 1)).To(gomega.Succeed())
+				g.Expect(layer.DelimiterProcessEnv("web", "TEST_APPEND", ":")).To(gomega.Succeed())
+				g.Expect(layer.OverrideProcessEnv("web", "TEST_OVERRIDE", "%s-%d", "test-string", 1)).To(gomega.Succeed())
+				g.Expect(layer.PrependProcessEnv("web", "TEST_PREPEND", "%s-%d", "test-string", 1)).To(gomega.Succeed())
+				g.Expect(layer.PrependPathProcessEnv("web", "TEST_PREPEND_PATH", "%s-%d", "test-string", 1)).To(gomega.Succeed())
+
+				dir := filepath.Join(root, "test-layer", "env.launch", "web")
+				g.Expect(filepath.Join(dir, "TEST_APPEND.append")).To(internal.HaveContent("test-string-1"))
+				g.Expect(filepath.Join(dir, "TEST_APPEND.delim")).To(internal.HaveContent(":"))
+				g.Expect(filepath.Join(dir, "TEST_DEFAULT.default")).To(internal.HaveContent("test-string-1"))
+				g.Expect(filepath.Join(dir, "TEST_OVERRIDE.override")).To(internal.HaveContent("test-string-1"))
+				g.Expect(filepath.Join(dir, "TEST_PREPEND.prepend")).To(internal.HaveContent("test-string-1"))
+				g.Expect(filepath.Join(dir, "TEST_PREPEND_PATH")).To(internal.HaveContent("test-string-1"))
+			})
+
+			it("writes prepend path environment files with a delimiter from buildpack API 0.5", func() {
+				layer = layers.Layers{Root: root, API: version.MustParse("0.5")}.Layer("test-layer")
+
+				g.Expect(layer.PrependPathProcessEnv("web", "TEST_PREPEND_PATH", "%s-%d", "test-string", 1)).To(gomega.Succeed())
+
+				dir := filepath.Join(root, "test-layer", "env.launch", "web")
+				g.Expect(filepath.Join(dir, "TEST_PREPEND_PATH.prepend")).To(internal.HaveContent("test-string-1"))
+				g.Expect(filepath.Join(dir, "TEST_PREPEND_PATH.delim")).To(internal.HaveContent(string(os.PathListSeparator)))
+				g.Expect(filepath.Join(dir, "TEST_PREPEND_PATH")).NotTo(gomega.BeAnExistingFile())
+			})
+
+			it("rejects invalid process types", func() {
+				g.Expect(layer.OverrideProcessEnv("../web", "TEST_OVERRIDE", "test-value")).
+					To(gomega.MatchError(`invalid process type "../web"`))
+				g.Expect(layer.OverrideProcessEnv("", "TEST_OVERRIDE", "test-value")).
+					To(gomega.MatchError(`invalid process type ""`))
+				g.Expect(layer.OverrideProcessEnv("..", "TEST_OVERRIDE", "test-value")).
+					To(gomega.MatchError(`invalid process type ".."`))
+			})
+
+			it("accepts process types containing dots", func() {
+				g.Expect(layer.OverrideProcessEnv("web.v1", "TEST_OVERRIDE", "test-value")).To(gomega.Succeed())
+
+				g.Expect(filepath.Join(root, "test-layer", "env.launch", "web.v1", "TEST_OVERRIDE.override")).
+					To(internal.HaveContent("test-value"))
+			})
+
 			it("writes a profile file", func() {
 				g.Expect(layer.WriteProfile("test-name", "%s-%d", "test-string", 1)).To(gomega.Succeed())
 
 				g.Expect(filepath.Join(root, "test-layer", "profile.d", "test-name")).To(internal.HaveContent("test-string-1"))
 			})
+
+			it("writes a credential profile that reads bindings at launch", func() {
+				g.Expect(layer.WriteCredentialProfile("credentials.sh",
+					layers.CredentialVariable{Name: "DB_PASSWORD", Type: "mysql", Key: "password"},
+					layers.CredentialVariable{Name: "DB_USERNAME", Type: "mysql", Key: "username"},
+					layers.CredentialVariable{Name: "API_KEY", Type: "test's-api", Key: "key"},
+				)).To(gomega.Succeed())
+
+				profile := filepath.Join(root, "test-layer", "profile.d", "credentials.sh")
+				b, err := ioutil.ReadFile(profile)
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+				g.Expect(string(b)).NotTo(gomega.ContainSubstring("test-password"))
+
+				bindings := filepath.Join(root, "bindings")
+				internal.WriteTestFile(t, filepath.Join(bindings, "kubernetes", "db", "type"), "mysql\n")
+				internal.WriteTestFile(t, filepath.Join(bindings, "kubernetes", "db", "password"), "test-password")
+				internal.WriteTestFile(t, filepath.Join(bindings, "cnb", "api", "metadata", "kind"), "test's-api")
+				internal.WriteTestFile(t, filepath.Join(bindings, "cnb", "api", "secret", "key"), "test-key")
+
+				cmd := exec.Command("sh", "-c", fmt.Sprintf(`. %s && echo "$DB_PASSWORD|${DB_USERNAME-unset}|$API_KEY"`, profile))
+				cmd.Env = []string{
+					fmt.Sprintf("SERVICE_BINDING_ROOT=%s", filepath.Join(bindings, "kubernetes")),
+					fmt.Sprintf("CNB_BINDINGS=%s", filepath.Join(bindings, "cnb")),
+				}
+				out, err := cmd.CombinedOutput()
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+				g.Expect(string(out)).To(gomega.Equal("test-password|unset|test-key\n"))
+			})
+
+			it("installs exec.d programs with ordering prefixes", func() {
+				program := filepath.Join(root, "test-program")
+				internal.WriteTestFile(t, program, "test-program")
+
+				layer = layers.Layers{Root: root, API: version.MustParse("0.5")}.Layer("test-layer")
+				g.Expect(layer.WriteExecD(1, "test-name", program)).To(gomega.Succeed())
+
+				f := filepath.Join(root, "test-layer", "exec.d", "01-test-name")
+				g.Expect(f).To(internal.HaveContent("test-program"))
+
+				s, err := os.Stat(f)
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+				g.Expect(s.Mode().Perm()).To(gomega.Equal(os.FileMode(0755)))
+			})
+
+			it("rejects invalid exec.d orders and names", func() {
+				program := filepath.Join(root, "test-program")
+				internal.WriteTestFile(t, program, "test-program")
+
+				layer = layers.Layers{Root: root, API: version.MustParse("0.5")}.Layer("test-layer")
+
+				g.Expect(layer.WriteExecD(-1, "test-name", program)).
+					To(gomega.MatchError("exec.d order must be between 0 and 99: -1"))
+				g.Expect(layer.WriteExecD(100, "test-name", program)).
+					To(gomega.MatchError("exec.d order must be between 0 and 99: 100"))
+				g.Expect(layer.WriteExecD(1, "a/../../x", program)).
+					To(gomega.MatchError(`invalid exec.d program name "a/../../x"`))
+				g.Expect(layer.WriteExecD(1, "", program)).
+					To(gomega.MatchError(`invalid exec.d program name ""`))
+			})
+
+			it("does not install exec.d programs before buildpack API 0.5", func() {
+				layer = layers.Layers{Root: root, API: version.MustParse("0.4")}.Layer("test-layer")
+
+				g.Expect(layer.WriteExecD(1, "test-name", filepath.Join(root, "test-program"))).
+					To(gomega.MatchError("exec.d requires buildpack API 0.5 or later, buildpack API is 0.4"))
+			})
+
+			it("rejects invalid credential variable names", func() {
+				g.Expect(layer.WriteCredentialProfile("credentials.sh", layers.CredentialVariable{Name: "INVALID-NAME"})).
+					To(gomega.MatchError(`invalid environment variable name "INVALID-NAME"`))
+			})
+
+			it("rejects credential keys that are not file names", func() {
+				for _, key := range []string{"", ".", "..", "../type", "secret/password"} {
+					g.Expect(layer.WriteCredentialProfile("credentials.sh", layers.CredentialVariable{Name: "PASSWORD", Type: "test-type", Key: key})).
+						To(gomega.MatchError(fmt.Sprintf("invalid credential key %q", key)))
+				}
+			})
+		})
+
+		when("contribute", func() {
+
+			type metadata struct {
+				Alpha string
+				Bravo int
+			}
+
+			var (
+				root  string
+				layer layers.Layer
+			)
+
+			it.Before(func() {
+				root = internal.ScratchDir(t, "layer")
+				layer = layers.Layers{Root: root}.Layer("test-layer")
+			})
+
+			it("contributes a layer when metadata does not exist", func() {
+				called := false
+
+				g.Expect(layer.Contribute(metadata{"test-value", 1}, func(layer layers.Layer) error {
+					called = true
+					internal.WriteTestFile(t, filepath.Join(layer.Root, "test-file"), "test-content")
+					return nil
+				}, layers.Launch)).To(gomega.Succeed())
+
+				g.Expect(called).To(gomega.BeTrue())
+				g.Expect(filepath.Join(root, "test-layer", "test-file")).To(internal.HaveContent("test-content"))
+				g.Expect(filepath.Join(root, "test-layer.toml")).To(internal.HaveContent(`build = false
+cache = false
+launch = true
+
+[metadata]
+  Alpha = "test-value"
+  Bravo = 1
+`))
+			})
+
+			it("reuses a layer when metadata matches", func() {
+				internal.WriteTestFile(t, filepath.Join(root, "test-layer.toml"), `[metadata]
+Alpha = "test-value"
+Bravo = 1
+`)
+
+				called := false
+
+				g.Expect(layer.Contribute(metadata{"test-value", 1}, func(layer layers.Layer) error {
+					called = true
+					return nil
+				}, layers.Cache)).To(gomega.Succeed())
+
+				g.Expect(called).To(gomega.BeFalse())
+				g.Expect(filepath.Join(root, "test-layer.toml")).To(internal.HaveContent(`build = false
+cache = true
+launch = false
+
+[metadata]
+  Alpha = "test-value"
+  Bravo = 1
+`))
+			})
+
+			it("removes and recontributes a layer when metadata does not match", func() {
+				internal.WriteTestFile(t, filepath.Join(root, "test-layer.toml"), `[metadata]
+Alpha = "test-value"
+Bravo = 2
+`)
+				internal.WriteTestFile(t, filepath.Join(root, "test-layer", "stale-file"), "stale-content")
+
+				called := false
+
+				g.Expect(layer.Contribute(metadata{"test-value", 1}, func(layer layers.Layer) error {
+					called = true
+					return nil
+				})).To(gomega.Succeed())
+
+				g.Expect(called).To(gomega.BeTrue())
+				g.Expect(filepath.Join(root, "test-layer", "stale-file")).NotTo(gomega.BeAnExistingFile())
+				g.Expect(filepath.Join(root, "test-layer")).To(gomega.BeADirectory())
+			})
+
+			it("matches metadata written from a map", func() {
+				g.Expect(layer.WriteMetadata(map[string]interface{}{"Alpha": "test-value", "Bravo": 1})).To(gomega.Succeed())
+
+				g.Expect(layer.MetadataMatches(metadata{"test-value", 1})).To(gomega.BeTrue())
+			})
+
+			it("does not write metadata when contributor fails", func() {
+				g.Expect(layer.Contribute(metadata{"test-value", 1}, func(layer layers.Layer) error {
+					return fmt.Errorf("test-error")
+				})).To(gomega.MatchError("test-error"))
+
+				g.Expect(filepath.Join(root, "test-layer.toml")).NotTo(gomega.BeAnExistingFile())
+			})
+
+			it("removes stale metadata when contributor fails", func() {
+				internal.WriteTestFile(t, filepath.Join(root, "test-layer.toml"), `[metadata]
+Alpha = "test-value"
+Bravo = 2
+`)
+
+				g.Expect(layer.Contribute(metadata{"test-value", 1}, func(layer layers.Layer) error {
+					return fmt.Errorf("test-error")
+				})).To(gomega.MatchError("test-error"))
+
+				g.Expect(filepath.Join(root, "test-layer.toml")).NotTo(gomega.BeAnExistingFile())
+
+				called := false
+
+				g.Expect(layer.Contribute(metadata{"test-value", 2}, func(layer layers.Layer) error {
+					called = true
+					return nil
+				})).To(gomega.Succeed())
+
+				g.Expect(called).To(gomega.BeTrue())
+			})
 		})
 
 		when("environment files", func() {
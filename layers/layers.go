@@ -20,8 +20,11 @@ import (
 	"fmt"
 	"path/filepath"
 
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/version"
 )
 
 // Layers represents the layers for an application.
@@ -29,21 +32,158 @@ type Layers struct {
 	// Root is the path to the root directory for the layers.
 	Root string
 
+	// API is the buildpack API version that determines the format of the files written.  A zero value writes the
+	// format of the lowest supported API.
+	API version.Version
+
+	// DependencyCaches are the paths to directories that are consulted for dependency artifacts before downloading.
+	DependencyCaches []string
+
+	// Offline indicates that dependency artifacts must not be downloaded.
+	Offline bool
+
 	logger logger.Logger
 }
 
+// DependencyLayer creates a DependencyLayer for a dependency.  The layer is named after the dependency's ID.
+func (l Layers) DependencyLayer(dependency buildpack.Dependency) DependencyLayer {
+	return DependencyLayer{l.Layer(dependency.ID), dependency, l.DownloadLayer(dependency)}
+}
+
+// DownloadLayer creates a DownloadLayer for a dependency.  The layer is named after the dependency's SHA256.
+func (l Layers) DownloadLayer(dependency buildpack.Dependency) DownloadLayer {
+	return DownloadLayer{l.Layer(dependency.SHA256), dependency, l.DependencyCaches, l.Offline}
+}
+
 // Layer creates a Layer with a specified name.
 func (l Layers) Layer(name string) Layer {
 	metadata := filepath.Join(l.Root, fmt.Sprintf("%s.toml", name))
-	return Layer{filepath.Join(l.Root, name), metadata, l.logger}
+	return Layer{filepath.Join(l.Root, name), metadata, l.API, l.logger}
+}
+
+// MergeApplicationMetadata merges application metadata into any existing launch.toml, so that several steps of a build
+// can contribute to it.  See Metadata.Merge.
+func (l Layers) MergeApplicationMetadata(metadata Metadata) error {
+	existing, err := l.ReadApplicationMetadata()
+	if err != nil {
+		return err
+	}
+
+	return l.WriteApplicationMetadata(existing.Merge(metadata))
+}
+
+// ReadApplicationMetadata reads application metadata from the filesystem.  If launch.toml does not exist, empty metadata
+// is returned.
+func (l Layers) ReadApplicationMetadata() (Metadata, error) {
+	f := filepath.Join(l.Root, "launch.toml")
+
+	var m launchTOML
+	if err := readTomlFile(f, &m); err != nil {
+		return Metadata{}, err
+	}
+
+	metadata, err := m.metadata()
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	l.logger.Debug("Reading application metadata: %s => %v", f, metadata)
+	return metadata, nil
 }
 
-// WriteApplicationMetadata writes application metadata to the filesystem.
+// WriteApplicationMetadata writes application metadata to the filesystem.  An error is returned if the metadata uses
+// features that are not available in the buildpack API version.
 func (l Layers) WriteApplicationMetadata(metadata Metadata) error {
+	if err := metadata.validate(l.API); err != nil {
+		return err
+	}
+
 	f := filepath.Join(l.Root, "launch.toml")
 
 	l.logger.Debug("Writing application metadata: %s <= %v", f, metadata)
-	return internal.WriteTomlFile(f, 0644, metadata)
+	return internal.WriteTomlFile(f, 0644, newLaunchTOML(metadata, l.API))
+}
+
+// WriteBuildBOM writes the bill of materials available at build time as build.sbom.cdx.json and build.sbom.spdx.json
+// files, and as the legacy [[bom]] tables of build.toml, replacing any existing legacy entries.  The SBOM files are only
+// written from buildpack API 0.7.
+func (l Layers) WriteBuildBOM(bom BOM) error {
+	f := filepath.Join(l.Root, "build.toml")
+
+	var b buildTOML
+	if err := readTomlFile(f, &b); err != nil {
+		return err
+	}
+	b.BOM = bom.legacy(l.API)
+
+	l.logger.Debug("Writing build BOM: %s <= %v", f, bom)
+	if err := internal.WriteTomlFile(f, 0644, b); err != nil {
+		return err
+	}
+
+	if l.API.LessThan(sbomAPI) {
+		return nil
+	}
+
+	return writeSBOM(filepath.Join(l.Root, "build"), "build", bom)
+}
+
+// WriteBuildMetadata writes build metadata to the filesystem.  An error is returned if the metadata uses features that
+// are not available in the buildpack API version.
+func (l Layers) WriteBuildMetadata(metadata BuildMetadata) error {
+	if err := metadata.validate(l.API); err != nil {
+		return err
+	}
+
+	f := filepath.Join(l.Root, "build.toml")
+
+	l.logger.Debug("Writing build metadata: %s <= %v", f, metadata)
+	return internal.WriteTomlFile(f, 0644, buildTOML{metadata.Unmet, metadata.BOM.legacy(l.API)})
+}
+
+// WriteUnmet writes the [[unmet]] tables of build.toml, replacing any existing unmet plan entries.  The BOM already
+// written to build.toml is preserved.  An error is returned if unmet plan entries are not available in the buildpack
+// API version.
+func (l Layers) WriteUnmet(unmet UnmetPlanEntries) error {
+	if err := unmet.validate(l.API); err != nil {
+		return err
+	}
+
+	f := filepath.Join(l.Root, "build.toml")
+
+	var b buildTOML
+	if err := readTomlFile(f, &b); err != nil {
+		return err
+	}
+	b.Unmet = unmet
+
+	l.logger.Debug("Writing unmet plan entries: %s <= %v", f, unmet)
+	return internal.WriteTomlFile(f, 0644, b)
+}
+
+// WriteLaunchBOM writes the bill of materials available at launch time as launch.sbom.cdx.json and
+// launch.sbom.spdx.json files, and as the legacy [[bom]] tables of launch.toml, replacing any existing legacy entries.
+// Processes and slices already written to launch.toml are preserved.  The SBOM files are only written from buildpack
+// API 0.7.
+func (l Layers) WriteLaunchBOM(bom BOM) error {
+	f := filepath.Join(l.Root, "launch.toml")
+
+	var m launchTOML
+	if err := readTomlFile(f, &m); err != nil {
+		return err
+	}
+	m.BOM = bom.legacy(l.API)
+
+	l.logger.Debug("Writing launch BOM: %s <= %v", f, bom)
+	if err := internal.WriteTomlFile(f, 0644, m); err != nil {
+		return err
+	}
+
+	if l.API.LessThan(sbomAPI) {
+		return nil
+	}
+
+	return writeSBOM(filepath.Join(l.Root, "launch"), "launch", bom)
 }
 
 // WritePersistentMetadata writes persistent metadata to the filesystem.
@@ -56,11 +196,25 @@ func (l Layers) WritePersistentMetadata(metadata interface{}) error {
 	return internal.WriteTomlFile(f, 0644, pm)
 }
 
+func readTomlFile(file string, v interface{}) error {
+	exists, err := internal.FileExists(file)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return nil
+	}
+
+	_, err = toml.DecodeFile(file, v)
+	return err
+}
+
 type persistentMetadata struct {
 	Metadata interface{} `toml:"metadata"`
 }
 
-// NewLayers creates a new Logger instance.
+// NewLayers creates a new Layers instance.
 func NewLayers(root string, logger logger.Logger) Layers {
-	return Layers{root, logger}
+	return Layers{Root: root, logger: logger}
 }
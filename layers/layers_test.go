@@ -22,6 +22,7 @@ import (
 
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/buildpacks/libbuildpack/v2/version"
 	"github.com/onsi/gomega"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -86,6 +87,99 @@ func TestLayers(t *testing.T) {
 `))
 		})
 
+		it("writes labels, default processes, working directories, and command arrays", func() {
+			g.Expect(layers.Layers{Root: root, API: version.MustParse("0.9")}.WriteApplicationMetadata(layers.Metadata{
+				Labels: layers.Labels{{Key: "test-key", Value: "test-value"}},
+				Processes: layers.Processes{
+					{Type: "web", CommandArray: []string{"command-1", "arg-1"}, Default: true, WorkingDirectory: "/workspace"},
+				},
+			})).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(root, "launch.toml")).To(internal.HaveContent(`[[labels]]
+  key = "test-key"
+  value = "test-value"
+
+[[processes]]
+  type = "web"
+  command = ["command-1", "arg-1"]
+  default = true
+  working-dir = "/workspace"
+`))
+		})
+
+		it("writes commands and direct according to the buildpack API", func() {
+			metadata := layers.Metadata{Processes: layers.Processes{{Type: "web", Command: "command-1", Direct: true}}}
+
+			g.Expect(layers.Layers{Root: root, API: version.MustParse("0.8")}.WriteApplicationMetadata(metadata)).To(gomega.Succeed())
+			g.Expect(filepath.Join(root, "launch.toml")).To(internal.HaveContent(`[[processes]]
+  type = "web"
+  command = "command-1"
+  direct = true
+`))
+
+			g.Expect(layers.Layers{Root: root, API: version.MustParse("0.9")}.WriteApplicationMetadata(metadata)).To(gomega.Succeed())
+			g.Expect(filepath.Join(root, "launch.toml")).To(internal.HaveContent(`[[processes]]
+  type = "web"
+  command = ["command-1"]
+`))
+		})
+
+		it("validates application metadata against the buildpack API", func() {
+			l := layers.Layers{Root: root, API: version.MustParse("0.5")}
+
+			g.Expect(l.WriteApplicationMetadata(layers.Metadata{Processes: layers.Processes{{Type: "web", Default: true}}})).
+				To(gomega.MatchError("default process web requires buildpack API 0.6 or later, buildpack API is 0.5"))
+			g.Expect(l.WriteApplicationMetadata(layers.Metadata{Processes: layers.Processes{{Type: "web", WorkingDirectory: "/workspace"}}})).
+				To(gomega.MatchError("working directory of process web requires buildpack API 0.8 or later, buildpack API is 0.5"))
+			g.Expect(l.WriteApplicationMetadata(layers.Metadata{Processes: layers.Processes{{Type: "web", CommandArray: []string{"command"}}}})).
+				To(gomega.MatchError("command array of process web requires buildpack API 0.9 or later, buildpack API is 0.5"))
+			g.Expect(layers.Layers{Root: root, API: version.MustParse("0.4")}.WriteApplicationMetadata(layers.Metadata{Labels: layers.Labels{{Key: "test-key"}}})).
+				To(gomega.MatchError("labels require buildpack API 0.5 or later, buildpack API is 0.4"))
+			g.Expect(layers.Layers{Root: root, API: version.MustParse("0.6")}.WriteApplicationMetadata(layers.Metadata{
+				Processes: layers.Processes{{Type: "web", Default: true}, {Type: "task", Default: true}},
+			})).To(gomega.MatchError("only one process may be the default, found 2"))
+		})
+
+		it("reads and merges application metadata", func() {
+			l := layers.Layers{Root: root, API: version.MustParse("0.9")}
+
+			g.Expect(l.ReadApplicationMetadata()).To(gomega.BeZero())
+
+			g.Expect(l.WriteApplicationMetadata(layers.Metadata{
+				Labels:    layers.Labels{{Key: "key-1", Value: "value-1"}, {Key: "key-2", Value: "value-2"}},
+				Processes: layers.Processes{{Type: "web", Command: "command-1"}, {Type: "task", CommandArray: []string{"command-2"}}},
+				BOM:       layers.BOM{{Name: "test-name", Version: "1.0.0"}},
+			})).To(gomega.Succeed())
+
+			g.Expect(l.MergeApplicationMetadata(layers.Metadata{
+				Labels:    layers.Labels{{Key: "key-2", Value: "value-3"}},
+				Processes: layers.Processes{{Type: "web", Command: "command-3"}},
+			})).To(gomega.Succeed())
+
+			g.Expect(l.ReadApplicationMetadata()).To(gomega.Equal(layers.Metadata{
+				Labels:    layers.Labels{{Key: "key-1", Value: "value-1"}, {Key: "key-2", Value: "value-3"}},
+				Processes: layers.Processes{{Type: "task", CommandArray: []string{"command-2"}}, {Type: "web", CommandArray: []string{"command-3"}}},
+				Slices:    layers.Slices{},
+				BOM:       layers.BOM{{Name: "test-name", Version: "1.0.0"}},
+			}))
+		})
+
+		it("writes unmet plan entries to build metadata", func() {
+			l := layers.Layers{Root: root, API: version.MustParse("0.5")}
+
+			g.Expect(l.WriteBuildMetadata(layers.BuildMetadata{
+				Unmet: layers.UnmetPlanEntries{{Name: "test-unmet"}},
+			})).To(gomega.Succeed())
+
+			g.Expect(filepath.Join(root, "build.toml")).To(internal.HaveContent(`[[unmet]]
+  name = "test-unmet"
+`))
+
+			g.Expect(layers.Layers{Root: root, API: version.MustParse("0.4")}.WriteBuildMetadata(layers.BuildMetadata{
+				Unmet: layers.UnmetPlanEntries{{Name: "test-unmet"}},
+			})).To(gomega.MatchError("unmet plan entries require buildpack API 0.5 or later, buildpack API is 0.4"))
+		})
+
 		it("writes persistent metadata", func() {
 			g.Expect(layers.Layers{Root: root}.WritePersistentMetadata(metadata{"test-value", 1})).To(gomega.Succeed())
 
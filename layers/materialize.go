@@ -0,0 +1,164 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+)
+
+var (
+	buildRootDirectories = []rootDirectory{
+		{"bin", []string{"PATH"}},
+		{"lib", []string{"LD_LIBRARY_PATH", "LIBRARY_PATH"}},
+		{"include", []string{"CPATH"}},
+		{"pkgconfig", []string{"PKG_CONFIG_PATH"}},
+	}
+
+	launchRootDirectories = []rootDirectory{
+		{"bin", []string{"PATH"}},
+		{"lib", []string{"LD_LIBRARY_PATH"}},
+	}
+)
+
+type rootDirectory struct {
+	name      string
+	variables []string
+}
+
+// BuildEnvironment returns the environment that the lifecycle provides to later buildpacks at build time.  Starting
+// from the base environment, each layer flagged as Build is applied in order: its bin, lib, include, and pkgconfig
+// directories are prepended to the matching path variables and then its env and env.build variables are applied.
+func BuildEnvironment(base map[string]string, layers ...Layer) (map[string]string, error) {
+	return materialize(base, layers, func(t layerTypes) bool { return t.Build }, buildRootDirectories,
+		func(e EnvironmentVariable) bool {
+			return e.Process == "" && (e.Scope == ScopeShared || e.Scope == ScopeBuild)
+		})
+}
+
+// LaunchEnvironment returns the environment that the lifecycle provides to a process of a type at launch.  Starting
+// from the base environment, each layer flagged as Launch is applied in order: its bin and lib directories are
+// prepended to PATH and LD_LIBRARY_PATH and then its env, env.launch, and env.launch/<process> variables are applied.
+// If process is empty, no process-specific variables are applied.
+func LaunchEnvironment(base map[string]string, process string, layers ...Layer) (map[string]string, error) {
+	return materialize(base, layers, func(t layerTypes) bool { return t.Launch }, launchRootDirectories,
+		func(e EnvironmentVariable) bool {
+			return (e.Scope == ScopeShared || e.Scope == ScopeLaunch) && (e.Process == "" || e.Process == process)
+		})
+}
+
+func materialize(base map[string]string, layers []Layer, flagged func(layerTypes) bool,
+	directories []rootDirectory, applies func(EnvironmentVariable) bool) (map[string]string, error) {
+
+	environment := make(map[string]string, len(base))
+	for k, v := range base {
+		environment[k] = v
+	}
+
+	for _, l := range layers {
+		t, err := l.types()
+		if err != nil {
+			return nil, err
+		}
+
+		if !flagged(t) {
+			continue
+		}
+
+		for _, d := range directories {
+			if exists, err := internal.FileExists(filepath.Join(l.Root, d.name)); err != nil {
+				return nil, err
+			} else if !exists {
+				continue
+			}
+
+			for _, v := range d.variables {
+				apply(environment, EnvironmentVariable{Name: v, Action: ActionPrependPath, Value: filepath.Join(l.Root, d.name)})
+			}
+		}
+
+		e, err := l.Environment()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, v := range e {
+			if applies(v) {
+				apply(environment, v)
+			}
+		}
+	}
+
+	return environment, nil
+}
+
+func apply(environment map[string]string, variable EnvironmentVariable) {
+	existing, ok := environment[variable.Name]
+
+	switch variable.Action {
+	case ActionOverride:
+		environment[variable.Name] = variable.Value
+	case ActionDefault:
+		if !ok {
+			environment[variable.Name] = variable.Value
+		}
+	case ActionAppend:
+		if existing == "" {
+			environment[variable.Name] = variable.Value
+		} else {
+			environment[variable.Name] = existing + variable.Delimiter + variable.Value
+		}
+	case ActionPrepend:
+		if existing == "" {
+			environment[variable.Name] = variable.Value
+		} else {
+			environment[variable.Name] = variable.Value + variable.Delimiter + existing
+		}
+	case ActionPrependPath:
+		if existing == "" {
+			environment[variable.Name] = variable.Value
+		} else {
+			environment[variable.Name] = variable.Value + string(os.PathListSeparator) + existing
+		}
+	}
+}
+
+// types reads the flags of the layer from its metadata, in either the [types] table or the legacy top-level keys.
+func (l Layer) types() (layerTypes, error) {
+	exists, err := internal.FileExists(l.Metadata)
+	if err != nil || !exists {
+		return layerTypes{}, err
+	}
+
+	var m struct {
+		layerTypes
+		Types layerTypes `toml:"types"`
+	}
+
+	if _, err := toml.DecodeFile(l.Metadata, &m); err != nil {
+		return layerTypes{}, err
+	}
+
+	return layerTypes{
+		Build:  m.Build || m.Types.Build,
+		Cache:  m.Cache || m.Types.Cache,
+		Launch: m.Launch || m.Types.Launch,
+	}, nil
+}
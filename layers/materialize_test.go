@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/buildpacks/libbuildpack/v2/version"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestMaterialize(t *testing.T) {
+	spec.Run(t, "Materialize", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		var (
+			root   string
+			first  layers.Layer
+			second layers.Layer
+			cached layers.Layer
+		)
+
+		it.Before(func() {
+			root = internal.ScratchDir(t, "materialize")
+
+			first = layers.Layers{Root: root}.Layer("first")
+			g.Expect(first.WriteMetadata(nil, layers.Build, layers.Launch)).To(gomega.Succeed())
+			g.Expect(os.MkdirAll(filepath.Join(first.Root, "bin"), 0755)).To(gomega.Succeed())
+			g.Expect(os.MkdirAll(filepath.Join(first.Root, "lib"), 0755)).To(gomega.Succeed())
+			g.Expect(first.OverrideSharedEnv("TEST_OVERRIDE", "first")).To(gomega.Succeed())
+			g.Expect(first.AppendSharedEnv("TEST_APPEND", "first")).To(gomega.Succeed())
+			g.Expect(first.DelimiterSharedEnv("TEST_APPEND", ",")).To(gomega.Succeed())
+			g.Expect(first.DefaultBuildEnv("TEST_DEFAULT", "first")).To(gomega.Succeed())
+			g.Expect(first.OverrideLaunchEnv("TEST_LAUNCH", "first")).To(gomega.Succeed())
+
+			second = layers.Layers{Root: root, API: version.MustParse("0.6")}.Layer("second")
+			g.Expect(second.WriteMetadata(nil, layers.Build, layers.Launch)).To(gomega.Succeed())
+			g.Expect(os.MkdirAll(filepath.Join(second.Root, "bin"), 0755)).To(gomega.Succeed())
+			g.Expect(second.OverrideSharedEnv("TEST_OVERRIDE", "second")).To(gomega.Succeed())
+			g.Expect(second.AppendSharedEnv("TEST_APPEND", "second")).To(gomega.Succeed())
+			g.Expect(second.DelimiterSharedEnv("TEST_APPEND", ",")).To(gomega.Succeed())
+			g.Expect(second.DefaultBuildEnv("TEST_DEFAULT", "second")).To(gomega.Succeed())
+			g.Expect(second.PrependSharedEnv("TEST_PREPEND", "second")).To(gomega.Succeed())
+			g.Expect(second.DelimiterSharedEnv("TEST_PREPEND", ":")).To(gomega.Succeed())
+			g.Expect(internal.WriteFile(filepath.Join(second.Root, "env.launch", "web", "TEST_LAUNCH.override"), 0644, "web")).
+				To(gomega.Succeed())
+
+			cached = layers.Layers{Root: root}.Layer("cached")
+			g.Expect(cached.WriteMetadata(nil, layers.Cache)).To(gomega.Succeed())
+			g.Expect(cached.OverrideSharedEnv("TEST_OVERRIDE", "cached")).To(gomega.Succeed())
+		})
+
+		it("materializes the build environment", func() {
+			e, err := layers.BuildEnvironment(map[string]string{"PATH": "/usr/bin", "TEST_PREPEND": "base"},
+				first, second, cached)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(e).To(gomega.Equal(map[string]string{
+				"PATH":            filepath.Join(second.Root, "bin") + ":" + filepath.Join(first.Root, "bin") + ":/usr/bin",
+				"LD_LIBRARY_PATH": filepath.Join(first.Root, "lib"),
+				"LIBRARY_PATH":    filepath.Join(first.Root, "lib"),
+				"TEST_OVERRIDE":   "second",
+				"TEST_APPEND":     "first,second",
+				"TEST_DEFAULT":    "first",
+				"TEST_PREPEND":    "second:base",
+			}))
+		})
+
+		it("materializes the launch environment", func() {
+			e, err := layers.LaunchEnvironment(map[string]string{}, "", first, second, cached)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(e).To(gomega.HaveKeyWithValue("LD_LIBRARY_PATH", filepath.Join(first.Root, "lib")))
+			g.Expect(e).NotTo(gomega.HaveKey("LIBRARY_PATH"))
+			g.Expect(e).NotTo(gomega.HaveKey("TEST_DEFAULT"))
+			g.Expect(e).To(gomega.HaveKeyWithValue("TEST_LAUNCH", "first"))
+		})
+
+		it("materializes the launch environment of a process type", func() {
+			e, err := layers.LaunchEnvironment(map[string]string{}, "web", first, second, cached)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(e).To(gomega.HaveKeyWithValue("TEST_LAUNCH", "web"))
+		})
+
+		it("applies environment files without a suffix according to the buildpack API", func() {
+			old := layers.Layers{Root: root}.Layer("old")
+			g.Expect(old.WriteMetadata(nil, layers.Build)).To(gomega.Succeed())
+			g.Expect(internal.WriteFile(filepath.Join(old.Root, "env", "TEST_UNSUFFIXED"), 0644, "old")).To(gomega.Succeed())
+
+			current := layers.Layers{Root: root, API: version.MustParse("0.5")}.Layer("current")
+			g.Expect(current.WriteMetadata(nil, layers.Build)).To(gomega.Succeed())
+			g.Expect(internal.WriteFile(filepath.Join(current.Root, "env", "TEST_OVERRIDE"), 0644, "current")).To(gomega.Succeed())
+			g.Expect(current.PrependPathSharedEnv("TEST_PATH", "current")).To(gomega.Succeed())
+
+			e, err := layers.BuildEnvironment(map[string]string{"TEST_UNSUFFIXED": "base", "TEST_OVERRIDE": "base", "TEST_PATH": "base"},
+				old, current)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(e).To(gomega.HaveKeyWithValue("TEST_UNSUFFIXED", "old:base"))
+			g.Expect(e).To(gomega.HaveKeyWithValue("TEST_OVERRIDE", "current"))
+			g.Expect(e).To(gomega.HaveKeyWithValue("TEST_PATH", "current:base"))
+		})
+	}, spec.Report(report.Terminal{}))
+}
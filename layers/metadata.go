@@ -16,11 +16,212 @@
 
 package layers
 
+import (
+	"fmt"
+
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+var (
+	labelsAPI           = version.MustParse("0.5")
+	defaultProcessAPI   = version.MustParse("0.6")
+	workingDirectoryAPI = version.MustParse("0.8")
+	commandArrayAPI     = version.MustParse("0.9")
+	directAPI           = version.MustParse("0.9")
+	unmetAPI            = version.MustParse("0.5")
+)
+
 // Metadata represents metadata about the Launch.
 type Metadata struct {
+	// Labels is a collection of image labels.  Requires buildpack API 0.5 or later.
+	Labels Labels `toml:"labels"`
+
 	// Processes is a collection of processes.
 	Processes Processes `toml:"processes"`
 
 	// Slices is a collection of slices.
 	Slices Slices `toml:"slices"`
+
+	// BOM is the bill of materials available at launch, written as legacy [[bom]] tables.
+	BOM BOM `toml:"-"`
+}
+
+// Merge returns the metadata with the contributions of other added.  Processes and labels in other replace those in
+// the metadata with the same type or key; slices and BOM entries are appended.
+func (m Metadata) Merge(other Metadata) Metadata {
+	merged := Metadata{
+		Slices: append(append(Slices{}, m.Slices...), other.Slices...),
+		BOM:    append(append(BOM{}, m.BOM...), other.BOM...),
+	}
+
+	for _, l := range m.Labels {
+		if !other.Labels.contains(l.Key) {
+			merged.Labels = append(merged.Labels, l)
+		}
+	}
+	merged.Labels = append(merged.Labels, other.Labels...)
+
+	for _, p := range m.Processes {
+		if !other.Processes.contains(p.Type) {
+			merged.Processes = append(merged.Processes, p)
+		}
+	}
+	merged.Processes = append(merged.Processes, other.Processes...)
+
+	return merged
+}
+
+// validate returns an error if the metadata uses features that are not available in a buildpack API version.
+func (m Metadata) validate(api version.Version) error {
+	if len(m.Labels) > 0 && api.LessThan(labelsAPI) {
+		return fmt.Errorf("labels require buildpack API %s or later, buildpack API is %s", labelsAPI, api)
+	}
+
+	defaults := 0
+	for _, p := range m.Processes {
+		if p.Default {
+			if api.LessThan(defaultProcessAPI) {
+				return fmt.Errorf("default process %s requires buildpack API %s or later, buildpack API is %s",
+					p.Type, defaultProcessAPI, api)
+			}
+			defaults++
+		}
+
+		if p.WorkingDirectory != "" && api.LessThan(workingDirectoryAPI) {
+			return fmt.Errorf("working directory of process %s requires buildpack API %s or later, buildpack API is %s",
+				p.Type, workingDirectoryAPI, api)
+		}
+
+		if len(p.CommandArray) > 0 && api.LessThan(commandArrayAPI) {
+			return fmt.Errorf("command array of process %s requires buildpack API %s or later, buildpack API is %s",
+				p.Type, commandArrayAPI, api)
+		}
+	}
+
+	if defaults > 1 {
+		return fmt.Errorf("only one process may be the default, found %d", defaults)
+	}
+
+	return nil
+}
+
+type launchTOML struct {
+	Labels    Labels           `toml:"labels,omitempty"`
+	Processes []processTOML    `toml:"processes"`
+	Slices    Slices           `toml:"slices"`
+	BOM       []legacyBOMEntry `toml:"bom,omitempty"`
+}
+
+func newLaunchTOML(metadata Metadata, api version.Version) launchTOML {
+	l := launchTOML{Labels: metadata.Labels, Slices: metadata.Slices, BOM: metadata.BOM.legacy(api)}
+
+	for _, p := range metadata.Processes {
+		var command interface{} = p.Command
+		if len(p.CommandArray) > 0 {
+			command = p.CommandArray
+		} else if !api.LessThan(commandArrayAPI) && p.Command != "" {
+			command = []string{p.Command}
+		}
+
+		process := processTOML{
+			Type:             p.Type,
+			Command:          command,
+			Args:             p.Args,
+			Default:          p.Default,
+			WorkingDirectory: p.WorkingDirectory,
+		}
+
+		if api.LessThan(directAPI) {
+			direct := p.Direct
+			process.Direct = &direct
+		}
+
+		l.Processes = append(l.Processes, process)
+	}
+
+	return l
+}
+
+func (l launchTOML) metadata() (Metadata, error) {
+	m := Metadata{Labels: l.Labels, Slices: l.Slices, BOM: fromLegacyBOM(l.BOM)}
+
+	for _, p := range l.Processes {
+		process := Process{
+			Type:             p.Type,
+			Args:             p.Args,
+			Default:          p.Default,
+			WorkingDirectory: p.WorkingDirectory,
+		}
+
+		if p.Direct != nil {
+			process.Direct = *p.Direct
+		}
+
+		switch c := p.Command.(type) {
+		case nil:
+		case string:
+			process.Command = c
+		case []interface{}:
+			for _, e := range c {
+				s, ok := e.(string)
+				if !ok {
+					return Metadata{}, fmt.Errorf("command of process %s must be an array of strings", p.Type)
+				}
+				process.CommandArray = append(process.CommandArray, s)
+			}
+		default:
+			return Metadata{}, fmt.Errorf("command of process %s must be a string or an array of strings", p.Type)
+		}
+
+		m.Processes = append(m.Processes, process)
+	}
+
+	return m, nil
+}
+
+type processTOML struct {
+	Type             string      `toml:"type"`
+	Command          interface{} `toml:"command"`
+	Args             []string    `toml:"args"`
+	Direct           *bool       `toml:"direct,omitempty"`
+	Default          bool        `toml:"default,omitempty"`
+	WorkingDirectory string      `toml:"working-dir,omitempty"`
+}
+
+// BuildMetadata represents metadata about the Build.
+type BuildMetadata struct {
+	// BOM is the bill of materials available at build time, written as legacy [[bom]] tables.
+	BOM BOM
+
+	// Unmet is a collection of buildpack plan entries that the buildpack did not satisfy.  The lifecycle passes them to
+	// subsequent buildpacks.  Requires buildpack API 0.5 or later.
+	Unmet UnmetPlanEntries
+}
+
+// validate returns an error if the metadata uses features that are not available in a buildpack API version.
+func (b BuildMetadata) validate(api version.Version) error {
+	return b.Unmet.validate(api)
+}
+
+// UnmetPlanEntries is a collection of UnmetPlanEntry instances.
+type UnmetPlanEntries []UnmetPlanEntry
+
+// UnmetPlanEntry represents a buildpack plan entry that the buildpack did not satisfy.
+type UnmetPlanEntry struct {
+	// Name is the name of the buildpack plan entry.
+	Name string `toml:"name"`
+}
+
+// validate returns an error if unmet plan entries are not available in a buildpack API version.
+func (u UnmetPlanEntries) validate(api version.Version) error {
+	if len(u) > 0 && api.LessThan(unmetAPI) {
+		return fmt.Errorf("unmet plan entries require buildpack API %s or later, buildpack API is %s", unmetAPI, api)
+	}
+
+	return nil
+}
+
+type buildTOML struct {
+	Unmet UnmetPlanEntries `toml:"unmet,omitempty"`
+	BOM   []legacyBOMEntry `toml:"bom,omitempty"`
 }
@@ -24,12 +24,34 @@ type Process struct {
 	// Type is the type of the process.
 	Type string `toml:"type"`
 
-	// Command is the command of the process.
+	// Command is the command of the process.  From buildpack API 0.9 it is written as a command array with a single
+	// element, and so is read back as CommandArray.
 	Command string `toml:"command"`
 
 	// Args are arguments to the command.
 	Args []string `toml:"args"`
 
-	// Command is exec'd directly by the os (no profile.d scripts run)
+	// CommandArray is the command of the process as an array of the executable and its leading arguments.  If set, it
+	// is written in place of Command.  Requires buildpack API 0.9 or later.
+	CommandArray []string `toml:"-"`
+
+	// Command is exec'd directly by the os (no profile.d scripts run).  Ignored from buildpack API 0.9, where processes
+	// are always exec'd directly.
 	Direct bool `toml:"direct"`
+
+	// Default indicates that the process is the default process of the image.  Requires buildpack API 0.6 or later.
+	Default bool `toml:"default,omitempty"`
+
+	// WorkingDirectory is the directory the process is started in.  Requires buildpack API 0.8 or later.
+	WorkingDirectory string `toml:"working-dir,omitempty"`
+}
+
+func (p Processes) contains(processType string) bool {
+	for _, process := range p {
+		if process.Type == processType {
+			return true
+		}
+	}
+
+	return false
 }
@@ -0,0 +1,220 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package layers
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+const (
+	// CycloneDXExtension is the extension of CycloneDX JSON SBOM files.
+	CycloneDXExtension = "cdx.json"
+
+	// SPDXExtension is the extension of SPDX JSON SBOM files.
+	SPDXExtension = "spdx.json"
+)
+
+// sbomAPI is the buildpack API version from which the lifecycle reads SBOM files.
+var sbomAPI = version.MustParse("0.7")
+
+var spdxIDCharacters = regexp.MustCompile(`[^A-Za-z0-9.\-]+`)
+
+// writeSBOM writes the BOM as <prefix>.sbom.cdx.json and <prefix>.sbom.spdx.json.
+func writeSBOM(prefix string, name string, bom BOM) error {
+	b, err := json.MarshalIndent(cycloneDX(bom), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := internal.WriteFile(fmt.Sprintf("%s.sbom.%s", prefix, CycloneDXExtension), 0644, "%s\n", b); err != nil {
+		return err
+	}
+
+	s, err := spdx(name, bom)
+	if err != nil {
+		return err
+	}
+
+	b, err = json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return internal.WriteFile(fmt.Sprintf("%s.sbom.%s", prefix, SPDXExtension), 0644, "%s\n", b)
+}
+
+type cycloneDXDocument struct {
+	BOMFormat   string               `json:"bomFormat"`
+	SpecVersion string               `json:"specVersion"`
+	Version     int                  `json:"version"`
+	Components  []cycloneDXComponent `json:"components"`
+}
+
+type cycloneDXComponent struct {
+	Type     string             `json:"type"`
+	Name     string             `json:"name"`
+	Version  string             `json:"version,omitempty"`
+	PURL     string             `json:"purl,omitempty"`
+	CPE      string             `json:"cpe,omitempty"`
+	Licenses []cycloneDXLicense `json:"licenses,omitempty"`
+	Hashes   []cycloneDXHash    `json:"hashes,omitempty"`
+}
+
+type cycloneDXLicense struct {
+	License struct {
+		ID string `json:"id"`
+	} `json:"license"`
+}
+
+type cycloneDXHash struct {
+	Algorithm string `json:"alg"`
+	Content   string `json:"content"`
+}
+
+func cycloneDX(bom BOM) cycloneDXDocument {
+	d := cycloneDXDocument{BOMFormat: "CycloneDX", SpecVersion: "1.3", Version: 1, Components: []cycloneDXComponent{}}
+
+	for _, e := range bom {
+		c := cycloneDXComponent{Type: "library", Name: e.Name, Version: e.Version, PURL: e.PURL}
+
+		if len(e.CPEs) > 0 {
+			c.CPE = e.CPEs[0]
+		}
+
+		for _, l := range e.Licenses {
+			var license cycloneDXLicense
+			license.License.ID = l
+			c.Licenses = append(c.Licenses, license)
+		}
+
+		if e.Checksum.Hash != "" {
+			c.Hashes = append(c.Hashes, cycloneDXHash{cycloneDXAlgorithm(e.Checksum.Algorithm), e.Checksum.Hash})
+		}
+
+		d.Components = append(d.Components, c)
+	}
+
+	return d
+}
+
+type spdxDocument struct {
+	SPDXVersion       string           `json:"spdxVersion"`
+	DataLicense       string           `json:"dataLicense"`
+	SPDXID            string           `json:"SPDXID"`
+	Name              string           `json:"name"`
+	DocumentNamespace string           `json:"documentNamespace"`
+	CreationInfo      spdxCreationInfo `json:"creationInfo"`
+	Packages          []spdxPackage    `json:"packages"`
+}
+
+type spdxCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+func spdx(name string, bom BOM) (spdxDocument, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return spdxDocument{}, err
+	}
+
+	d := spdxDocument{
+		SPDXVersion:       "SPDX-2.2",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              name,
+		DocumentNamespace: fmt.Sprintf("https://spdx.org/spdxdocs/%s-%x", name, id),
+		CreationInfo: spdxCreationInfo{
+			Created:  time.Now().UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: libbuildpack"},
+		},
+		Packages: []spdxPackage{},
+	}
+
+	for i, e := range bom {
+		p := spdxPackage{
+			SPDXID:           fmt.Sprintf("SPDXRef-Package-%d-%s", i, spdxIDCharacters.ReplaceAllString(e.Name, "-")),
+			Name:             e.Name,
+			VersionInfo:      e.Version,
+			DownloadLocation: "NOASSERTION",
+			LicenseConcluded: "NOASSERTION",
+			LicenseDeclared:  "NOASSERTION",
+			CopyrightText:    "NOASSERTION",
+		}
+
+		if len(e.Licenses) > 0 {
+			p.LicenseDeclared = strings.Join(e.Licenses, " AND ")
+		}
+
+		if e.Checksum.Hash != "" {
+			p.Checksums = append(p.Checksums, spdxChecksum{strings.ToUpper(e.Checksum.Algorithm), e.Checksum.Hash})
+		}
+
+		if e.PURL != "" {
+			p.ExternalRefs = append(p.ExternalRefs, spdxExternalRef{"PACKAGE_MANAGER", "purl", e.PURL})
+		}
+
+		for _, cpe := range e.CPEs {
+			p.ExternalRefs = append(p.ExternalRefs, spdxExternalRef{"SECURITY", "cpe23Type", cpe})
+		}
+
+		d.Packages = append(d.Packages, p)
+	}
+
+	return d, nil
+}
+
+// cycloneDXAlgorithm converts an SPDX checksum algorithm (SHA256) to its CycloneDX form (SHA-256).
+func cycloneDXAlgorithm(algorithm string) string {
+	a := strings.ToUpper(algorithm)
+	if strings.HasPrefix(a, "SHA") && !strings.HasPrefix(a, "SHA-") && len(a) > 3 {
+		return "SHA-" + a[3:]
+	}
+
+	return a
+}
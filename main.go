@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package libbuildpack provides a single entrypoint for buildpacks that ship one binary, symlinked as both bin/detect
+// and bin/build.
+package libbuildpack
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/buildpacks/libbuildpack/v2/build"
+	"github.com/buildpacks/libbuildpack/v2/detect"
+	"github.com/buildpacks/libbuildpack/v2/logger"
+)
+
+// ErrorStatusCode is the status code returned when detection or build produces an error without a more specific code.
+const ErrorStatusCode = 1
+
+// Detector is called during detection.  It should return the result of detect.Detect.Pass(), detect.Detect.Fail(), or
+// detect.Detect.Error().
+type Detector func(detect detect.Detect) (int, error)
+
+// Builder is called during build.  It should return the result of build.Build.Success() or build.Build.Failure().
+type Builder func(build build.Build) (int, error)
+
+// Main is the entrypoint for a buildpack.  It runs the detector or builder, depending on the name the binary was
+// invoked with, and exits with the resulting status code.
+func Main(detector Detector, builder Builder) {
+	os.Exit(Run(detector, builder))
+}
+
+// Run runs the detector if the binary was invoked as detect and the builder if the binary was invoked as build.  It
+// returns the status code the process should exit with.  Errors are printed through the logger.
+func Run(detector Detector, builder Builder) int {
+	switch c := filepath.Base(os.Args[0]); c {
+	case "detect":
+		return runDetect(detector)
+	case "build":
+		return runBuild(builder)
+	default:
+		logger.NewLogger(nil, os.Stderr).Info("unsupported command %s, must be one of detect or build", c)
+		return ErrorStatusCode
+	}
+}
+
+func runDetect(detector Detector) int {
+	d, err := detect.DefaultDetect()
+	if err != nil {
+		logger.NewLogger(nil, os.Stderr).Info("unable to create default detect: %s", err)
+		return ErrorStatusCode
+	}
+
+	code, err := detector(d)
+	if err != nil {
+		d.Logger.Info("%s", err)
+
+		if code <= 0 || code == detect.FailStatusCode {
+			code = ErrorStatusCode
+		}
+
+		return d.Error(code)
+	}
+
+	return code
+}
+
+func runBuild(builder Builder) int {
+	b, err := build.DefaultBuild()
+	if err != nil {
+		logger.NewLogger(nil, os.Stderr).Info("unable to create default build: %s", err)
+		return ErrorStatusCode
+	}
+
+	code, err := builder(b)
+	if err != nil {
+		b.Logger.Info("%s", err)
+
+		if code <= 0 {
+			code = ErrorStatusCode
+		}
+
+		return b.Failure(code)
+	}
+
+	return code
+}
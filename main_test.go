@@ -0,0 +1,130 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package libbuildpack_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2"
+	"github.com/buildpacks/libbuildpack/v2/build"
+	"github.com/buildpacks/libbuildpack/v2/detect"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestRun(t *testing.T) {
+	spec.Run(t, "Run", func(t *testing.T, when spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		var root string
+
+		it.Before(func() {
+			root = internal.ScratchDir(t, "main")
+			internal.TouchTestFile(t, root, "buildpack.toml")
+			internal.TouchTestFile(t, root, "plan.toml")
+		})
+
+		detector := func(code int, err error) libbuildpack.Detector {
+			return func(detect detect.Detect) (int, error) { return code, err }
+		}
+
+		builder := func(code int, err error) libbuildpack.Builder {
+			return func(build build.Build) (int, error) { return code, err }
+		}
+
+		when("detect", func() {
+
+			var restore func()
+
+			it.Before(func() {
+				restore = internal.ReplaceArgs(t, filepath.Join(root, "bin", "detect"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))
+			})
+
+			it.After(func() {
+				restore()
+			})
+
+			it("runs the detector", func() {
+				defer internal.ReplaceWorkingDirectory(t, root)()
+				defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+
+				called := false
+				g.Expect(libbuildpack.Run(func(d detect.Detect) (int, error) {
+					called = true
+					return d.Fail(), nil
+				}, nil)).To(gomega.Equal(detect.FailStatusCode))
+				g.Expect(called).To(gomega.BeTrue())
+			})
+
+			it("converts errors into an error status code", func() {
+				defer internal.ReplaceWorkingDirectory(t, root)()
+				defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+				_, restoreConsole := internal.ReplaceConsole(t)
+				defer restoreConsole()
+
+				g.Expect(libbuildpack.Run(detector(-1, fmt.Errorf("test-error")), nil)).
+					To(gomega.Equal(libbuildpack.ErrorStatusCode))
+				g.Expect(libbuildpack.Run(detector(detect.FailStatusCode, fmt.Errorf("test-error")), nil)).
+					To(gomega.Equal(libbuildpack.ErrorStatusCode))
+				g.Expect(libbuildpack.Run(detector(42, fmt.Errorf("test-error")), nil)).To(gomega.Equal(42))
+			})
+		})
+
+		when("build", func() {
+
+			var restore func()
+
+			it.Before(func() {
+				restore = internal.ReplaceArgs(t, filepath.Join(root, "bin", "build"), filepath.Join(root, "layers"), filepath.Join(root, "platform"), filepath.Join(root, "plan.toml"))
+			})
+
+			it.After(func() {
+				restore()
+			})
+
+			it("runs the builder", func() {
+				defer internal.ReplaceWorkingDirectory(t, root)()
+				defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+
+				g.Expect(libbuildpack.Run(nil, builder(build.SuccessStatusCode, nil))).To(gomega.Equal(build.SuccessStatusCode))
+			})
+
+			it("converts errors into an error status code", func() {
+				defer internal.ReplaceWorkingDirectory(t, root)()
+				defer internal.ReplaceEnv(t, "CNB_STACK_ID", "test-stack")()
+				_, restoreConsole := internal.ReplaceConsole(t)
+				defer restoreConsole()
+
+				g.Expect(libbuildpack.Run(nil, builder(-1, fmt.Errorf("test-error")))).
+					To(gomega.Equal(libbuildpack.ErrorStatusCode))
+			})
+		})
+
+		it("returns an error status code for unsupported commands", func() {
+			defer internal.ReplaceArgs(t, filepath.Join(root, "bin", "test"))()
+			_, restoreConsole := internal.ReplaceConsole(t)
+			defer restoreConsole()
+
+			g.Expect(libbuildpack.Run(nil, nil)).To(gomega.Equal(libbuildpack.ErrorStatusCode))
+		})
+	}, spec.Report(report.Terminal{}))
+}
@@ -0,0 +1,43 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/buildpacks/libbuildpack/v2/version"
+)
+
+// DefaultAPI is the platform API version assumed when CNB_PLATFORM_API is not set.
+var DefaultAPI = version.MustParse("0.4")
+
+// EnvironmentAPI returns the platform API version declared by the lifecycle in CNB_PLATFORM_API.  If it is not set,
+// DefaultAPI is returned.
+func EnvironmentAPI() (version.Version, error) {
+	s, ok := os.LookupEnv("CNB_PLATFORM_API")
+	if !ok || s == "" {
+		return DefaultAPI, nil
+	}
+
+	v, err := version.NewVersion(s)
+	if err != nil {
+		return version.Version{}, fmt.Errorf("unable to parse CNB_PLATFORM_API: %w", err)
+	}
+
+	return v, nil
+}
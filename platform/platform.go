@@ -17,12 +17,21 @@
 package platform
 
 import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/version"
 )
 
 // Platform represents the platform contributions for an application.
 type Platform struct {
+	// API is the platform API version the lifecycle implements, as declared in CNB_PLATFORM_API.
+	API version.Version
+
 	// Root is the path to the root directory for the platform contributions.
 	Root string
 
@@ -32,6 +41,23 @@ type Platform struct {
 	logger logger.Logger
 }
 
+// DependencyCache returns the path to the dependency cache supplied by the platform.
+func (p Platform) DependencyCache() string {
+	return filepath.Join(p.Root, "dependency-cache")
+}
+
+// Offline returns whether dependencies must not be downloaded.  It is enabled by setting BP_OFFLINE to true either in
+// the process environment or in the platform environment variables.
+func (p Platform) Offline() bool {
+	s, ok := os.LookupEnv("BP_OFFLINE")
+	if !ok {
+		s = p.EnvironmentVariables["BP_OFFLINE"]
+	}
+
+	offline, err := strconv.ParseBool(strings.TrimSpace(s))
+	return err == nil && offline
+}
+
 // DefaultPlatform creates a new instance of Platform.
 func DefaultPlatform(root string, logger logger.Logger) (Platform, error) {
 	if logger.IsDebugEnabled() {
@@ -42,10 +68,16 @@ func DefaultPlatform(root string, logger logger.Logger) (Platform, error) {
 		logger.Debug("Platform contents: %s", contents)
 	}
 
+	api, err := EnvironmentAPI()
+	if err != nil {
+		return Platform{}, err
+	}
+	logger.Debug("Platform API: %s", api)
+
 	environmentVariables, err := environmentVariables(root, logger)
 	if err != nil {
 		return Platform{}, err
 	}
 
-	return Platform{root, environmentVariables, logger}, err
+	return Platform{api, root, environmentVariables, logger}, err
 }
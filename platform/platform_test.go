@@ -17,12 +17,14 @@
 package platform_test
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/buildpacks/libbuildpack/v2/internal"
 	"github.com/buildpacks/libbuildpack/v2/logger"
 	"github.com/buildpacks/libbuildpack/v2/platform"
+	"github.com/buildpacks/libbuildpack/v2/version"
 	"github.com/onsi/gomega"
 	"github.com/sclevine/spec"
 	"github.com/sclevine/spec/report"
@@ -43,5 +45,51 @@ func TestPlatform(t *testing.T) {
 
 			g.Expect(platform.EnvironmentVariables).To(gomega.HaveKey("TEST_KEY"))
 		})
+
+		it("is offline when BP_OFFLINE is set by the platform", func() {
+			root := internal.ScratchDir(t, "platform")
+			defer internal.ProtectEnv(t, "BP_OFFLINE")()
+			g.Expect(os.Unsetenv("BP_OFFLINE")).To(gomega.Succeed())
+
+			p, err := platform.DefaultPlatform(root, logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+			g.Expect(p.Offline()).To(gomega.BeFalse())
+
+			internal.WriteTestFile(t, filepath.Join(root, "env", "BP_OFFLINE"), "true")
+
+			p, err = platform.DefaultPlatform(root, logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+			g.Expect(p.Offline()).To(gomega.BeTrue())
+		})
+
+		it("is offline when BP_OFFLINE is set in the environment", func() {
+			defer internal.ReplaceEnv(t, "BP_OFFLINE", "true")()
+
+			g.Expect(platform.Platform{}.Offline()).To(gomega.BeTrue())
+		})
+
+		it("defaults the platform API when CNB_PLATFORM_API is not set", func() {
+			defer internal.ProtectEnv(t, "CNB_PLATFORM_API")()
+			g.Expect(os.Unsetenv("CNB_PLATFORM_API")).To(gomega.Succeed())
+
+			p, err := platform.DefaultPlatform(internal.ScratchDir(t, "platform"), logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+			g.Expect(p.API).To(gomega.Equal(platform.DefaultAPI))
+		})
+
+		it("parses CNB_PLATFORM_API", func() {
+			defer internal.ReplaceEnv(t, "CNB_PLATFORM_API", "0.6")()
+
+			p, err := platform.DefaultPlatform(internal.ScratchDir(t, "platform"), logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+			g.Expect(p.API.Equal(version.MustParse("0.6"))).To(gomega.BeTrue())
+		})
+
+		it("returns an error when CNB_PLATFORM_API is invalid", func() {
+			defer internal.ReplaceEnv(t, "CNB_PLATFORM_API", "test-api")()
+
+			_, err := platform.DefaultPlatform(internal.ScratchDir(t, "platform"), logger.Logger{})
+			g.Expect(err).To(gomega.HaveOccurred())
+		})
 	}, spec.Report(report.Terminal{}))
 }
@@ -0,0 +1,240 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package resolution simulates the lifecycle's build plan resolution for a group of buildpacks, so that the plans
+// emitted at detect time can be tested for composition without running a build.
+package resolution
+
+import (
+	"fmt"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpackplan"
+	"github.com/buildpacks/libbuildpack/v2/buildplan"
+)
+
+// Buildpack is the outcome of detection for a buildpack in a group.
+type Buildpack struct {
+	// ID is the id of the buildpack.
+	ID string
+
+	// Optional indicates that the group may pass without the buildpack.
+	Optional bool
+
+	// Passed indicates that detection passed.
+	Passed bool
+
+	// Plans are the build plans written by the buildpack when detection passed.
+	Plans buildplan.Plans
+}
+
+// Result is the outcome of a successful resolution.
+type Result struct {
+	// Buildpacks are the ids of the buildpacks that participate in the build, in group order.
+	Buildpacks []string
+
+	// Plans are the buildpack plans that each participating buildpack receives at build time, keyed by buildpack id.
+	Plans map[string]buildpackplan.Plans
+}
+
+// Resolve runs the lifecycle's resolution algorithm over a group of buildpacks.  Each combination of alternatives is
+// tried in order, primary plans first.  Within a combination, optional buildpacks with unmet requires or unrequired
+// provides are dropped until every require is provided by the same or an earlier buildpack and every provide is
+// required by the same or a later buildpack.  The first combination that succeeds determines the result.  An error
+// describing why the last combination failed is returned if none succeed.
+func Resolve(group ...Buildpack) (Result, error) {
+	var passed []Buildpack
+
+	for _, bp := range group {
+		if bp.Passed {
+			passed = append(passed, bp)
+		} else if !bp.Optional {
+			return Result{}, fmt.Errorf("buildpack %s failed detection", bp.ID)
+		}
+	}
+
+	if len(passed) == 0 {
+		return Result{}, fmt.Errorf("no buildpacks passed detection")
+	}
+
+	deps, trial, err := runTrials(passed, nil)
+	if err != nil {
+		return Result{}, err
+	}
+
+	result := Result{Plans: make(map[string]buildpackplan.Plans)}
+	for _, o := range trial {
+		result.Buildpacks = append(result.Buildpacks, o.buildpack.ID)
+		result.Plans[o.buildpack.ID] = deps.plans(o.buildpack.ID)
+	}
+
+	return result, nil
+}
+
+type option struct {
+	buildpack Buildpack
+	plan      buildplan.Plan
+}
+
+type trial []option
+
+func (t trial) remove(id string) trial {
+	var r trial
+	for _, o := range t {
+		if o.buildpack.ID != id {
+			r = append(r, o)
+		}
+	}
+
+	return r
+}
+
+func runTrials(buildpacks []Buildpack, prefix trial) (depMap, trial, error) {
+	if len(prefix) == len(buildpacks) {
+		return runTrial(prefix)
+	}
+
+	var err error
+	for _, p := range buildpacks[len(prefix)].Plans.Alternatives() {
+		t := append(append(trial{}, prefix...), option{buildpacks[len(prefix)], p})
+
+		var (
+			deps     depMap
+			resolved trial
+		)
+		if deps, resolved, err = runTrials(buildpacks, t); err == nil {
+			return deps, resolved, nil
+		}
+	}
+
+	return depMap{}, nil, err
+}
+
+func runTrial(t trial) (depMap, trial, error) {
+	for {
+		deps := newDepMap(t)
+
+		name, bp, require, ok := deps.unmet()
+		if !ok {
+			if len(t) == 0 {
+				return depMap{}, nil, fmt.Errorf("no buildpacks remain after resolution")
+			}
+
+			return deps, t, nil
+		}
+
+		if !bp.Optional {
+			if require {
+				return depMap{}, nil, fmt.Errorf("buildpack %s requires %s, which is not provided by a previous buildpack", bp.ID, name)
+			}
+
+			return depMap{}, nil, fmt.Errorf("buildpack %s provides %s, which is not required by a later buildpack", bp.ID, name)
+		}
+
+		t = t.remove(bp.ID)
+	}
+}
+
+type depEntry struct {
+	providers     []Buildpack
+	requires      []buildplan.Required
+	extraProvides []Buildpack
+	earlyRequires []Buildpack
+}
+
+type depMap struct {
+	names   []string
+	entries map[string]*depEntry
+}
+
+func newDepMap(t trial) depMap {
+	m := depMap{entries: make(map[string]*depEntry)}
+
+	for _, o := range t {
+		for _, p := range o.plan.Provides {
+			e := m.entry(p.Name)
+			e.extraProvides = append(e.extraProvides, o.buildpack)
+		}
+
+		for _, r := range o.plan.Requires {
+			e := m.entry(r.Name)
+			e.providers = append(e.providers, e.extraProvides...)
+			e.extraProvides = nil
+
+			if len(e.providers) == 0 {
+				e.earlyRequires = append(e.earlyRequires, o.buildpack)
+			} else {
+				e.requires = append(e.requires, r)
+			}
+		}
+	}
+
+	return m
+}
+
+func (m *depMap) entry(name string) *depEntry {
+	e, ok := m.entries[name]
+	if !ok {
+		e = &depEntry{}
+		m.entries[name] = e
+		m.names = append(m.names, name)
+	}
+
+	return e
+}
+
+// unmet returns the first buildpack with a require that is not provided, or if there is none, the first buildpack with
+// a provide that is not required.  It also returns the name of the entry and whether it is an unmet require.
+func (m depMap) unmet() (string, Buildpack, bool, bool) {
+	for _, n := range m.names {
+		if e := m.entries[n]; len(e.earlyRequires) > 0 {
+			return n, e.earlyRequires[0], true, true
+		}
+	}
+
+	for _, n := range m.names {
+		if e := m.entries[n]; len(e.extraProvides) > 0 {
+			return n, e.extraProvides[0], false, true
+		}
+	}
+
+	return "", Buildpack{}, false, false
+}
+
+// plans returns the buildpack plans for every entry that a buildpack provides.
+func (m depMap) plans(id string) buildpackplan.Plans {
+	var plans buildpackplan.Plans
+
+	for _, n := range m.names {
+		e := m.entries[n]
+
+		for _, p := range e.providers {
+			if p.ID != id {
+				continue
+			}
+
+			for _, r := range e.requires {
+				plans.Entries = append(plans.Entries, buildpackplan.Plan{
+					Name:     r.Name,
+					Version:  r.Version,
+					Metadata: buildpackplan.Metadata(r.Metadata),
+				})
+			}
+			break
+		}
+	}
+
+	return plans
+}
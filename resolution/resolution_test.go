@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package resolution_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpackplan"
+	"github.com/buildpacks/libbuildpack/v2/buildplan"
+	"github.com/buildpacks/libbuildpack/v2/resolution"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestResolution(t *testing.T) {
+	spec.Run(t, "Resolution", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		provides := func(names ...string) buildplan.Plan {
+			var p buildplan.Plan
+			for _, n := range names {
+				p.Provides = append(p.Provides, buildplan.Provided{Name: n})
+			}
+			return p
+		}
+
+		it("gives each provider the requires of later buildpacks", func() {
+			jdk := provides("jdk")
+			app := buildplan.Plan{Requires: []buildplan.Required{
+				{Name: "jdk", Version: "11.*", Metadata: buildplan.Metadata{"launch": true}},
+			}}
+
+			r, err := resolution.Resolve(
+				resolution.Buildpack{ID: "jdk", Passed: true, Plans: buildplan.Plans{Plan: jdk}},
+				resolution.Buildpack{ID: "app", Passed: true, Plans: buildplan.Plans{Plan: app}},
+			)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.Buildpacks).To(gomega.Equal([]string{"jdk", "app"}))
+			g.Expect(r.Plans["jdk"]).To(gomega.Equal(buildpackplan.Plans{Entries: []buildpackplan.Plan{
+				{Name: "jdk", Version: "11.*", Metadata: buildpackplan.Metadata{"launch": true}},
+			}}))
+			g.Expect(r.Plans["app"]).To(gomega.BeZero())
+		})
+
+		it("tries alternatives", func() {
+			r, err := resolution.Resolve(
+				resolution.Buildpack{ID: "jdk", Passed: true, Plans: buildplan.Plans{
+					Plan: provides("jdk", "jre"),
+					Or:   []buildplan.Plan{provides("jdk")},
+				}},
+				resolution.Buildpack{ID: "app", Passed: true, Plans: buildplan.Plans{
+					Plan: buildplan.Plan{Requires: []buildplan.Required{{Name: "jdk"}}},
+				}},
+			)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.Plans["jdk"]).To(gomega.Equal(buildpackplan.Plans{Entries: []buildpackplan.Plan{{Name: "jdk"}}}))
+		})
+
+		it("drops optional buildpacks", func() {
+			r, err := resolution.Resolve(
+				resolution.Buildpack{ID: "failed", Optional: true},
+				resolution.Buildpack{ID: "unrequired", Optional: true, Passed: true, Plans: buildplan.Plans{Plan: provides("test")}},
+				resolution.Buildpack{ID: "app", Passed: true},
+			)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.Buildpacks).To(gomega.Equal([]string{"app"}))
+		})
+
+		it("fails when a required buildpack fails detection", func() {
+			_, err := resolution.Resolve(resolution.Buildpack{ID: "app"})
+			g.Expect(err).To(gomega.MatchError("buildpack app failed detection"))
+		})
+
+		it("fails when a require is not provided by a previous buildpack", func() {
+			_, err := resolution.Resolve(
+				resolution.Buildpack{ID: "app", Passed: true, Plans: buildplan.Plans{
+					Plan: buildplan.Plan{Requires: []buildplan.Required{{Name: "jdk"}}},
+				}},
+				resolution.Buildpack{ID: "jdk", Passed: true, Plans: buildplan.Plans{Plan: provides("jdk")}},
+			)
+			g.Expect(err).To(gomega.MatchError("buildpack app requires jdk, which is not provided by a previous buildpack"))
+		})
+
+		it("fails when a provide is not required by a later buildpack", func() {
+			_, err := resolution.Resolve(
+				resolution.Buildpack{ID: "jdk", Passed: true, Plans: buildplan.Plans{Plan: provides("jdk")}},
+			)
+			g.Expect(err).To(gomega.MatchError("buildpack jdk provides jdk, which is not required by a later buildpack"))
+		})
+	}, spec.Report(report.Terminal{}))
+}
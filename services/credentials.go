@@ -16,7 +16,344 @@
 
 package services
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+)
+
 // Credentials is the collection of credentials available exposed by a service.
 // Great care should be used when handling credentials, as they expose sensitive information.
 // Buildpacks should only extract values at startup/runtime and not embed them in the image.
+//
+// Values arrive in different forms depending on the source of the binding: CNB_SERVICES values are decoded from JSON
+// while directory-based bindings are always strings.  The typed accessors convert between these forms.  Each returns
+// false if the key does not exist and an error if the value cannot be converted.
 type Credentials map[string]interface{}
+
+// String returns a credential as a string.  Numbers and booleans are formatted.  Surrounding whitespace, such as the
+// trailing newline of a binding file, is trimmed as it is for Int and Bool.  Use WriteFile for the unmodified value.
+func (c Credentials) String(key string) (string, bool, error) {
+	s, ok, err := c.raw(key)
+	return strings.TrimSpace(s), ok, err
+}
+
+// Int returns a credential as an int.  Strings are parsed and numbers must be integral.
+func (c Credentials) Int(key string) (int, bool, error) {
+	v, ok := c[key]
+	if !ok {
+		return 0, false, nil
+	}
+
+	i, err := toInt(v)
+	if err != nil {
+		return 0, true, fmt.Errorf("credential %s: %w", key, err)
+	}
+
+	return i, true, nil
+}
+
+// Bool returns a credential as a bool.  Strings are parsed with strconv.ParseBool.
+func (c Credentials) Bool(key string) (bool, bool, error) {
+	v, ok := c[key]
+	if !ok {
+		return false, false, nil
+	}
+
+	b, err := toBool(v)
+	if err != nil {
+		return false, true, fmt.Errorf("credential %s: %w", key, err)
+	}
+
+	return b, true, nil
+}
+
+// StringSlice returns a credential as a slice of strings.  Strings are parsed as a JSON array if they start with [ and
+// are otherwise split on commas.
+func (c Credentials) StringSlice(key string) ([]string, bool, error) {
+	v, ok := c[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	s, err := toStringSlice(v)
+	if err != nil {
+		return nil, true, fmt.Errorf("credential %s: %w", key, err)
+	}
+
+	return s, true, nil
+}
+
+// Map returns a nested credential as Credentials.  Strings are parsed as a JSON object.
+func (c Credentials) Map(key string) (Credentials, bool, error) {
+	v, ok := c[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	m, err := toMap(v)
+	if err != nil {
+		return nil, true, fmt.Errorf("credential %s: %w", key, err)
+	}
+
+	return m, true, nil
+}
+
+// URI returns a credential as a parsed URI.
+func (c Credentials) URI(key string) (*url.URL, bool, error) {
+	s, ok, err := c.String(key)
+	if !ok || err != nil {
+		return nil, ok, err
+	}
+
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, true, fmt.Errorf("credential %s: %w", key, err)
+	}
+
+	return u, true, nil
+}
+
+// Decode decodes the credentials into the struct pointed to by v.  Each exported field is populated from the
+// credential named by its credential tag, or by its field name if there is no tag.  A tag of "-" skips the field.
+// Fields may be strings, ints, bools, string slices, *url.URL, Credentials, or nested structs, and are converted with
+// the same rules as the typed accessors.  Fields without a matching credential are left unchanged.
+func (c Credentials) Decode(v interface{}) error {
+	p := reflect.ValueOf(v)
+	if p.Kind() != reflect.Ptr || p.IsNil() || p.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("credentials can only be decoded into a pointer to a struct, not %T", v)
+	}
+
+	return c.decode(p.Elem())
+}
+
+// WriteFile writes a credential, such as a certificate or private key, to a file that is only readable by its owner.
+// The value is written as is, without trimming.  Parent directories are created as required.
+func (c Credentials) WriteFile(key string, path string) error {
+	s, ok, err := c.raw(key)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("credential %s does not exist", key)
+	}
+
+	if err := internal.WriteFile(path, 0600, "%s", s); err != nil {
+		return err
+	}
+
+	return os.Chmod(path, 0600)
+}
+
+// raw returns a credential as a string without trimming it.
+func (c Credentials) raw(key string) (string, bool, error) {
+	v, ok := c[key]
+	if !ok {
+		return "", false, nil
+	}
+
+	s, err := toString(v)
+	if err != nil {
+		return "", true, fmt.Errorf("credential %s: %w", key, err)
+	}
+
+	return s, true, nil
+}
+
+var (
+	credentialsType = reflect.TypeOf(Credentials{})
+	urlType         = reflect.TypeOf(&url.URL{})
+)
+
+func (c Credentials) decode(s reflect.Value) error {
+	t := s.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		key := f.Name
+		if tag, ok := f.Tag.Lookup("credential"); ok {
+			if tag == "-" {
+				continue
+			}
+			key = tag
+		}
+
+		if _, ok := c[key]; !ok {
+			continue
+		}
+
+		if err := c.decodeField(key, s.Field(i)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c Credentials) decodeField(key string, field reflect.Value) error {
+	switch {
+	case field.Type() == urlType:
+		u, _, err := c.URI(key)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(u))
+	case field.Type() == credentialsType:
+		m, _, err := c.Map(key)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(m))
+	case field.Kind() == reflect.String:
+		s, _, err := c.String(key)
+		if err != nil {
+			return err
+		}
+		field.SetString(s)
+	case field.Kind() >= reflect.Int && field.Kind() <= reflect.Int64:
+		i, _, err := c.Int(key)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(i))
+	case field.Kind() == reflect.Bool:
+		b, _, err := c.Bool(key)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.String:
+		s, _, err := c.StringSlice(key)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(s).Convert(field.Type()))
+	case field.Kind() == reflect.Struct:
+		m, _, err := c.Map(key)
+		if err != nil {
+			return err
+		}
+		if err := m.decode(field); err != nil {
+			return fmt.Errorf("credential %s: %w", key, err)
+		}
+	default:
+		return fmt.Errorf("credential %s cannot be decoded into %s", key, field.Type())
+	}
+
+	return nil
+}
+
+func toString(v interface{}) (string, error) {
+	switch t := v.(type) {
+	case string:
+		return t, nil
+	case json.Number:
+		return t.String(), nil
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), nil
+	case int:
+		return strconv.Itoa(t), nil
+	case int64:
+		return strconv.FormatInt(t, 10), nil
+	case bool:
+		return strconv.FormatBool(t), nil
+	default:
+		return "", fmt.Errorf("unable to convert %T to a string", v)
+	}
+}
+
+func toInt(v interface{}) (int, error) {
+	switch t := v.(type) {
+	case int:
+		return t, nil
+	case int64:
+		return int(t), nil
+	case float64:
+		if t != math.Trunc(t) {
+			return 0, fmt.Errorf("%v is not an integer", t)
+		}
+		return int(t), nil
+	case json.Number:
+		return strconv.Atoi(t.String())
+	case string:
+		return strconv.Atoi(strings.TrimSpace(t))
+	default:
+		return 0, fmt.Errorf("unable to convert %T to an int", v)
+	}
+}
+
+func toBool(v interface{}) (bool, error) {
+	switch t := v.(type) {
+	case bool:
+		return t, nil
+	case string:
+		return strconv.ParseBool(strings.TrimSpace(t))
+	default:
+		return false, fmt.Errorf("unable to convert %T to a bool", v)
+	}
+}
+
+func toStringSlice(v interface{}) ([]string, error) {
+	switch t := v.(type) {
+	case []string:
+		return t, nil
+	case []interface{}:
+		var s []string
+		for _, e := range t {
+			c, err := toString(e)
+			if err != nil {
+				return nil, err
+			}
+			s = append(s, c)
+		}
+		return s, nil
+	case string:
+		t = strings.TrimSpace(t)
+		if strings.HasPrefix(t, "[") {
+			var a []interface{}
+			if err := json.Unmarshal([]byte(t), &a); err != nil {
+				return nil, err
+			}
+			return toStringSlice(a)
+		}
+
+		var s []string
+		for _, e := range strings.Split(t, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				s = append(s, e)
+			}
+		}
+		return s, nil
+	default:
+		return nil, fmt.Errorf("unable to convert %T to a string slice", v)
+	}
+}
+
+func toMap(v interface{}) (Credentials, error) {
+	switch t := v.(type) {
+	case Credentials:
+		return t, nil
+	case map[string]interface{}:
+		return t, nil
+	case string:
+		var m map[string]interface{}
+		if err := json.Unmarshal([]byte(t), &m); err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("unable to convert %T to a map", v)
+	}
+}
@@ -0,0 +1,156 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package services_test
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/services"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestCredentials(t *testing.T) {
+	spec.Run(t, "Credentials", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		credentials := services.Credentials{
+			"host":      "test-host",
+			"port":      float64(5432),
+			"port-file": "5432\n",
+			"host-file": "test-host\n",
+			"uri-file":  "postgres://test-host:5432/test-database\n",
+			"ssl":       "true",
+			"hosts":     "host-1, host-2",
+			"roles":     []interface{}{"role-1", "role-2"},
+			"uri":       "postgres://test-host:5432/test-database",
+			"nested":    `{"user": "test-user"}`,
+			"float":     1.5,
+		}
+
+		it("converts strings", func() {
+			s, ok, err := credentials.String("port")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(s).To(gomega.Equal("5432"))
+
+			s, _, err = credentials.String("host-file")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(s).To(gomega.Equal("test-host"))
+
+			_, ok, err = credentials.String("missing")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeFalse())
+		})
+
+		it("converts ints", func() {
+			i, _, err := credentials.Int("port")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(i).To(gomega.Equal(5432))
+
+			i, _, err = credentials.Int("port-file")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(i).To(gomega.Equal(5432))
+
+			_, _, err = credentials.Int("float")
+			g.Expect(err).To(gomega.MatchError("credential float: 1.5 is not an integer"))
+		})
+
+		it("converts bools", func() {
+			b, _, err := credentials.Bool("ssl")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(b).To(gomega.BeTrue())
+		})
+
+		it("converts string slices", func() {
+			s, _, err := credentials.StringSlice("hosts")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(s).To(gomega.Equal([]string{"host-1", "host-2"}))
+
+			s, _, err = credentials.StringSlice("roles")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(s).To(gomega.Equal([]string{"role-1", "role-2"}))
+		})
+
+		it("converts maps", func() {
+			m, _, err := credentials.Map("nested")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(m).To(gomega.Equal(services.Credentials{"user": "test-user"}))
+		})
+
+		it("converts URIs", func() {
+			u, _, err := credentials.URI("uri")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(u.Hostname()).To(gomega.Equal("test-host"))
+
+			u, _, err = credentials.URI("uri-file")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(u.Path).To(gomega.Equal("/test-database"))
+		})
+
+		it("decodes into a struct", func() {
+			var c struct {
+				Host     string
+				HostFile string   `credential:"host-file"`
+				Port     int      `credential:"port-file"`
+				SSL      bool     `credential:"ssl"`
+				Hosts    []string `credential:"hosts"`
+				URI      *url.URL `credential:"uri"`
+				Nested   struct {
+					User string `credential:"user"`
+				} `credential:"nested"`
+				Ignored string `credential:"-"`
+			}
+
+			g.Expect(credentials.Decode(&c)).To(gomega.Succeed())
+			g.Expect(c.Host).To(gomega.BeEmpty())
+			g.Expect(c.HostFile).To(gomega.Equal("test-host"))
+			g.Expect(c.Port).To(gomega.Equal(5432))
+			g.Expect(c.SSL).To(gomega.BeTrue())
+			g.Expect(c.Hosts).To(gomega.Equal([]string{"host-1", "host-2"}))
+			g.Expect(c.URI.Path).To(gomega.Equal("/test-database"))
+			g.Expect(c.Nested.User).To(gomega.Equal("test-user"))
+		})
+
+		it("does not decode into non-pointers", func() {
+			g.Expect(credentials.Decode(struct{}{})).To(gomega.HaveOccurred())
+		})
+
+		it("writes credentials to owner-readable files", func() {
+			root := internal.ScratchDir(t, "credentials")
+			path := filepath.Join(root, "certs", "host")
+
+			g.Expect(credentials.WriteFile("host", path)).To(gomega.Succeed())
+			g.Expect(path).To(internal.HaveContent("test-host"))
+
+			s, err := os.Stat(path)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(s.Mode().Perm()).To(gomega.Equal(os.FileMode(0600)))
+
+			g.Expect(credentials.WriteFile("host-file", path)).To(gomega.Succeed())
+			g.Expect(path).To(internal.HaveContent("test-host\n"))
+
+			g.Expect(credentials.WriteFile("missing", path)).To(gomega.MatchError("credential missing does not exist"))
+		})
+	}, spec.Report(report.Terminal{}))
+}
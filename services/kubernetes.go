@@ -0,0 +1,152 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package services
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/buildpacks/libbuildpack/v2/internal"
+)
+
+// kubernetesServices parses bindings laid out according to the Kubernetes Service Binding specification.  Each
+// directory in root is a binding named after the directory, containing a type file, an optional provider file, and a
+// file for each credential.
+func kubernetesServices(root string) (Services, error) {
+	paths, err := bindingDirectories(root)
+	if err != nil {
+		return Services{}, err
+	}
+
+	services := Services{}
+	for _, p := range paths {
+		service, err := kubernetesService(p)
+		if err != nil {
+			return Services{}, err
+		}
+
+		services = append(services, service)
+	}
+
+	return services, nil
+}
+
+func kubernetesService(path string) (Service, error) {
+	files, err := bindingFiles(path)
+	if err != nil {
+		return Service{}, err
+	}
+
+	service := Service{BindingName: filepath.Base(path), Credentials: Credentials{}}
+
+	for k, v := range files {
+		switch k {
+		case "type":
+			service.Label = strings.TrimSpace(v)
+		case "provider":
+			service.Provider = strings.TrimSpace(v)
+		default:
+			service.Credentials[k] = v
+		}
+	}
+
+	if service.Label == "" {
+		return Service{}, fmt.Errorf("binding %s does not have a type", service.BindingName)
+	}
+
+	return service, nil
+}
+
+// bindingDirectories returns the paths of the non-hidden directories in root.  If root does not exist, no paths are
+// returned.
+func bindingDirectories(root string) ([]string, error) {
+	if exists, err := internal.FileExists(root); err != nil {
+		return nil, err
+	} else if !exists {
+		return nil, nil
+	}
+
+	files, err := ioutil.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+
+		p := filepath.Join(root, f.Name())
+
+		s, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.IsDir() {
+			paths = append(paths, p)
+		}
+	}
+
+	return paths, nil
+}
+
+// bindingFiles returns the contents of the non-hidden files in path, keyed by file name.  If path does not exist, no
+// files are returned.
+func bindingFiles(path string) (map[string]string, error) {
+	if exists, err := internal.FileExists(path); err != nil {
+		return nil, err
+	} else if !exists {
+		return map[string]string{}, nil
+	}
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := make(map[string]string)
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), ".") {
+			continue
+		}
+
+		file := filepath.Join(path, f.Name())
+
+		s, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.IsDir() {
+			continue
+		}
+
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		contents[f.Name()] = string(b)
+	}
+
+	return contents, nil
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package services
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// platformServices parses bindings laid out in the CNB bindings format.  Each directory in root is a binding named
+// after the directory, containing a metadata directory with kind, provider, and tags files and a secret directory with
+// a file for each credential.  Directories without a metadata/kind file are not CNB bindings and are ignored.
+func platformServices(root string) (Services, error) {
+	paths, err := bindingDirectories(root)
+	if err != nil {
+		return Services{}, err
+	}
+
+	services := Services{}
+	for _, p := range paths {
+		service, ok, err := platformService(p)
+		if err != nil {
+			return Services{}, err
+		}
+
+		if ok {
+			services = append(services, service)
+		}
+	}
+
+	return services, nil
+}
+
+func platformService(path string) (Service, bool, error) {
+	metadata, err := bindingFiles(filepath.Join(path, "metadata"))
+	if err != nil {
+		return Service{}, false, err
+	}
+
+	if _, ok := metadata["kind"]; !ok {
+		return Service{}, false, nil
+	}
+
+	secret, err := bindingFiles(filepath.Join(path, "secret"))
+	if err != nil {
+		return Service{}, false, err
+	}
+
+	service := Service{
+		BindingName: filepath.Base(path),
+		Credentials: Credentials{},
+		Label:       strings.TrimSpace(metadata["kind"]),
+		Provider:    strings.TrimSpace(metadata["provider"]),
+	}
+
+	for _, t := range strings.Split(metadata["tags"], "\n") {
+		if t = strings.TrimSpace(t); t != "" {
+			service.Tags = append(service.Tags, t)
+		}
+	}
+
+	for k, v := range secret {
+		service.Credentials[k] = v
+	}
+
+	return service, true, nil
+}
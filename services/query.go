@@ -0,0 +1,170 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// AmbiguousServiceError is returned when more than one service matches a query that expects a single service.
+type AmbiguousServiceError struct {
+	// Query is a description of the query.
+	Query string
+
+	// Services are the services that matched the query.
+	Services Services
+}
+
+func (a AmbiguousServiceError) Error() string {
+	var names []string
+	for _, s := range a.Services {
+		names = append(names, s.name())
+	}
+
+	return fmt.Sprintf("%d services match %s: %s", len(a.Services), a.Query, strings.Join(names, ", "))
+}
+
+// Matcher selects services.  Each criterion that is set must be satisfied for a service to match.
+type Matcher struct {
+	// Label is a pattern that the label of the service must match.  Optional.
+	Label *regexp.Regexp
+
+	// Tag is a pattern that at least one tag of the service must match.  Optional.
+	Tag *regexp.Regexp
+
+	// CredentialKeys are the keys that the credentials of the service must contain.  Optional.
+	CredentialKeys []string
+}
+
+// Matches returns whether a service satisfies the matcher.
+func (m Matcher) Matches(service Service) bool {
+	if m.Label != nil && !m.Label.MatchString(service.Label) {
+		return false
+	}
+
+	if m.Tag != nil {
+		found := false
+		for _, t := range service.Tags {
+			if m.Tag.MatchString(t) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	for _, k := range m.CredentialKeys {
+		if _, ok := service.Credentials[k]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String makes Matcher satisfy the Stringer interface.
+func (m Matcher) String() string {
+	var criteria []string
+
+	if m.Label != nil {
+		criteria = append(criteria, fmt.Sprintf("label %s", m.Label))
+	}
+
+	if m.Tag != nil {
+		criteria = append(criteria, fmt.Sprintf("tag %s", m.Tag))
+	}
+
+	if len(m.CredentialKeys) > 0 {
+		criteria = append(criteria, fmt.Sprintf("credentials %s", strings.Join(m.CredentialKeys, ", ")))
+	}
+
+	if len(criteria) == 0 {
+		return "any service"
+	}
+
+	return strings.Join(criteria, " and ")
+}
+
+// Filter returns the services that satisfy the matcher.
+func (s Services) Filter(matcher Matcher) Services {
+	var filtered Services
+
+	for _, service := range s {
+		if matcher.Matches(service) {
+			filtered = append(filtered, service)
+		}
+	}
+
+	return filtered
+}
+
+// Find returns the single service that satisfies the matcher.  If no service matches, false is returned.  If more than
+// one service matches, an AmbiguousServiceError is returned.
+func (s Services) Find(matcher Matcher) (Service, bool, error) {
+	return s.find(matcher.String(), matcher.Matches)
+}
+
+// FindByLabel returns the single service with a label.
+func (s Services) FindByLabel(label string) (Service, bool, error) {
+	return s.find(fmt.Sprintf("label %s", label), func(service Service) bool {
+		return service.Label == label
+	})
+}
+
+// FindByName returns the single service with a binding name or instance name.
+func (s Services) FindByName(name string) (Service, bool, error) {
+	return s.find(fmt.Sprintf("name %s", name), func(service Service) bool {
+		return service.BindingName == name || service.InstanceName == name
+	})
+}
+
+// FindServiceByTag returns the single service with a tag.
+func (s Services) FindServiceByTag(tag string) (Service, bool, error) {
+	return s.find(fmt.Sprintf("tag %s", tag), func(service Service) bool {
+		for _, t := range service.Tags {
+			if t == tag {
+				return true
+			}
+		}
+
+		return false
+	})
+}
+
+func (s Services) find(query string, predicate func(Service) bool) (Service, bool, error) {
+	var matched Services
+
+	for _, service := range s {
+		if predicate(service) {
+			matched = append(matched, service)
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return Service{}, false, nil
+	case 1:
+		return matched[0], true, nil
+	default:
+		return Service{}, false, AmbiguousServiceError{Query: query, Services: matched}
+	}
+}
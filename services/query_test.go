@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package services_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/services"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestQuery(t *testing.T) {
+	spec.Run(t, "Query", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		mysql := services.Service{
+			BindingName: "test-mysql",
+			Label:       "p-mysql",
+			Tags:        []string{"mysql", "relational"},
+			Credentials: services.Credentials{"uri": "test-uri", "username": "test-username"},
+		}
+
+		postgres := services.Service{
+			InstanceName: "test-postgres",
+			Label:        "elephantsql",
+			Tags:         []string{"postgres", "relational"},
+			Credentials:  services.Credentials{"uri": "test-uri"},
+		}
+
+		s := services.Services{mysql, postgres}
+
+		it("finds a service by label", func() {
+			service, ok, err := s.FindByLabel("p-mysql")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(service).To(gomega.Equal(mysql))
+		})
+
+		it("finds a service by name", func() {
+			service, ok, err := s.FindByName("test-postgres")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(service).To(gomega.Equal(postgres))
+		})
+
+		it("finds a service by tag", func() {
+			service, ok, err := s.FindServiceByTag("mysql")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(service).To(gomega.Equal(mysql))
+		})
+
+		it("returns false when no service matches", func() {
+			_, ok, err := s.FindServiceByTag("redis")
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeFalse())
+		})
+
+		it("returns an error when more than one service matches", func() {
+			_, ok, err := s.FindServiceByTag("relational")
+			g.Expect(ok).To(gomega.BeFalse())
+			g.Expect(err).To(gomega.Equal(services.AmbiguousServiceError{Query: "tag relational", Services: s}))
+			g.Expect(err).To(gomega.MatchError("2 services match tag relational: test-mysql, test-postgres"))
+		})
+
+		it("finds a service by matcher", func() {
+			service, ok, err := s.Find(services.Matcher{
+				Tag:            regexp.MustCompile("^(mysql|postgres)$"),
+				CredentialKeys: []string{"uri", "username"},
+			})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(ok).To(gomega.BeTrue())
+			g.Expect(service).To(gomega.Equal(mysql))
+		})
+
+		it("filters services by matcher", func() {
+			g.Expect(s.Filter(services.Matcher{Label: regexp.MustCompile("sql")})).To(gomega.Equal(s))
+			g.Expect(s.Filter(services.Matcher{Label: regexp.MustCompile("^elephant")})).
+				To(gomega.Equal(services.Services{postgres}))
+		})
+	}, spec.Report(report.Terminal{}))
+}
@@ -30,9 +30,24 @@ type Service struct {
 	// Label is the type of service.
 	Label string `json:"label"`
 
+	// Provider is the provider of this service.
+	Provider string `json:"provider"`
+
 	// Plan is the plan type of this service.
 	Plan string `json:"plan"`
 
 	// Tags is the collection of tags of the service.
 	Tags []string `json:"tags"`
 }
+
+func (s Service) name() string {
+	if s.BindingName != "" {
+		return s.BindingName
+	}
+
+	if s.InstanceName != "" {
+		return s.InstanceName
+	}
+
+	return s.Label
+}
@@ -18,7 +18,9 @@ package services
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
+	"path/filepath"
 
 	"github.com/buildpacks/libbuildpack/v2/logger"
 	"github.com/buildpacks/libbuildpack/v2/platform"
@@ -27,14 +29,71 @@ import (
 // Services is a collection of services bound to the application.
 type Services []Service
 
-// DefaultServices creates a new instance of Services.
+// DefaultServices creates a new instance of Services.  Services are read from the Cloud Foundry-style CNB_SERVICES JSON,
+// from the CNB bindings in <platform>/bindings, and from the Kubernetes Service Binding directories in
+// SERVICE_BINDING_ROOT.  Environment variables are read from the process environment and then the platform environment
+// variables.  When SERVICE_BINDING_ROOT is <platform>/bindings, the bindings are only read as Kubernetes Service
+// Bindings.  An error is returned if more than one source declares a binding with the same name.
 func DefaultServices(platform platform.Platform, logger logger.Logger) (Services, error) {
-	s, ok := os.LookupEnv("CNB_SERVICES")
+	bindingRoot, hasBindingRoot := lookup(platform, "SERVICE_BINDING_ROOT")
 
-	if !ok {
-		s, ok = platform.EnvironmentVariables["CNB_SERVICES"]
+	sources := []struct {
+		name     string
+		services func() (Services, error)
+	}{
+		{"CNB_SERVICES", func() (Services, error) { return cnbServices(platform) }},
+		{"platform bindings", func() (Services, error) {
+			if platform.Root == "" {
+				return Services{}, nil
+			}
+
+			root := filepath.Join(platform.Root, "bindings")
+			if hasBindingRoot {
+				if same, err := samePath(root, bindingRoot); err != nil {
+					return Services{}, err
+				} else if same {
+					return Services{}, nil
+				}
+			}
+
+			return platformServices(root)
+		}},
+		{"SERVICE_BINDING_ROOT", func() (Services, error) {
+			if !hasBindingRoot {
+				return Services{}, nil
+			}
+			return kubernetesServices(bindingRoot)
+		}},
 	}
 
+	services := Services{}
+	declared := make(map[string]string)
+
+	for _, source := range sources {
+		s, err := source.services()
+		if err != nil {
+			return Services{}, err
+		}
+
+		for _, service := range s {
+			if service.BindingName != "" {
+				if previous, ok := declared[service.BindingName]; ok && previous != source.name {
+					return Services{}, fmt.Errorf("binding %s is declared by both %s and %s",
+						service.BindingName, previous, source.name)
+				}
+				declared[service.BindingName] = source.name
+			}
+
+			services = append(services, service)
+		}
+	}
+
+	logger.Debug("Services: %s", services)
+	return services, nil
+}
+
+func cnbServices(platform platform.Platform) (Services, error) {
+	s, ok := lookup(platform, "CNB_SERVICES")
 	if !ok {
 		return Services{}, nil
 	}
@@ -56,10 +115,49 @@ func DefaultServices(platform platform.Platform, logger logger.Logger) (Services
 		}
 	}
 
-	logger.Debug("Services: %s", services)
 	return services, nil
 }
 
+func lookup(platform platform.Platform, name string) (string, bool) {
+	if s, ok := os.LookupEnv(name); ok {
+		return s, true
+	}
+
+	s, ok := platform.EnvironmentVariables[name]
+	return s, ok
+}
+
+// samePath returns whether a and b resolve to the same path.  Paths that do not exist are compared after making them
+// absolute.
+func samePath(a string, b string) (bool, error) {
+	resolve := func(path string) (string, error) {
+		path, err := filepath.Abs(path)
+		if err != nil {
+			return "", err
+		}
+
+		if r, err := filepath.EvalSymlinks(path); err == nil {
+			return r, nil
+		} else if !os.IsNotExist(err) {
+			return "", err
+		}
+
+		return path, nil
+	}
+
+	a, err := resolve(a)
+	if err != nil {
+		return false, err
+	}
+
+	b, err = resolve(b)
+	if err != nil {
+		return false, err
+	}
+
+	return a == b, nil
+}
+
 func parseService(raw json.RawMessage) (Service, error) {
 	var service Service
 
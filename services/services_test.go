@@ -17,6 +17,8 @@
 package services_test
 
 import (
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/buildpacks/libbuildpack/v2/internal"
@@ -99,5 +101,119 @@ func TestServices(t *testing.T) {
 				},
 			}))
 		})
+
+		it("parses Kubernetes service bindings in SERVICE_BINDING_ROOT", func() {
+			root := internal.ScratchDir(t, "services")
+			defer internal.ReplaceEnv(t, "SERVICE_BINDING_ROOT", root)()
+
+			internal.WriteTestFile(t, filepath.Join(root, "test-binding", "type"), "test-type\n")
+			internal.WriteTestFile(t, filepath.Join(root, "test-binding", "provider"), "test-provider")
+			internal.WriteTestFile(t, filepath.Join(root, "test-binding", "username"), "test-username")
+			internal.WriteTestFile(t, filepath.Join(root, "test-binding", "password"), "test-password")
+			internal.WriteTestFile(t, filepath.Join(root, "test-binding", "..data", "username"), "hidden")
+
+			s, err := services.DefaultServices(platform.Platform{}, logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+
+			g.Expect(s).To(gomega.Equal(services.Services{
+				{
+					BindingName: "test-binding",
+					Credentials: services.Credentials{"username": "test-username", "password": "test-password"},
+					Label:       "test-type",
+					Provider:    "test-provider",
+				},
+			}))
+		})
+
+		it("returns an error for Kubernetes service bindings without a type", func() {
+			root := internal.ScratchDir(t, "services")
+			defer internal.ReplaceEnv(t, "SERVICE_BINDING_ROOT", root)()
+
+			internal.WriteTestFile(t, filepath.Join(root, "test-binding", "username"), "test-username")
+
+			_, err := services.DefaultServices(platform.Platform{}, logger.Logger{})
+			g.Expect(err).To(gomega.MatchError("binding test-binding does not have a type"))
+		})
+
+		it("reads SERVICE_BINDING_ROOT from the platform", func() {
+			root := internal.ScratchDir(t, "services")
+			defer internal.ProtectEnv(t, "SERVICE_BINDING_ROOT")()
+			g.Expect(os.Unsetenv("SERVICE_BINDING_ROOT")).To(gomega.Succeed())
+
+			internal.WriteTestFile(t, filepath.Join(root, "test-binding", "type"), "test-type")
+
+			s, err := services.DefaultServices(platform.Platform{
+				EnvironmentVariables: platform.EnvironmentVariables{"SERVICE_BINDING_ROOT": root},
+			}, logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+
+			g.Expect(s).To(gomega.HaveLen(1))
+			g.Expect(s[0].Label).To(gomega.Equal("test-type"))
+		})
+
+		it("parses CNB bindings in the platform", func() {
+			root := internal.ScratchDir(t, "services")
+
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "metadata", "kind"), "test-kind")
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "metadata", "provider"), "test-provider")
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "metadata", "tags"), "tag-1\ntag-2\n")
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "secret", "username"), "test-username")
+
+			s, err := services.DefaultServices(platform.Platform{Root: root}, logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+
+			g.Expect(s).To(gomega.Equal(services.Services{
+				{
+					BindingName: "test-binding",
+					Credentials: services.Credentials{"username": "test-username"},
+					Label:       "test-kind",
+					Provider:    "test-provider",
+					Tags:        []string{"tag-1", "tag-2"},
+				},
+			}))
+		})
+
+		it("returns an error when sources declare the same binding", func() {
+			root := internal.ScratchDir(t, "services")
+			defer internal.ReplaceEnv(t, "SERVICE_BINDING_ROOT", filepath.Join(root, "kubernetes"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "metadata", "kind"), "test-kind")
+			internal.WriteTestFile(t, filepath.Join(root, "kubernetes", "test-binding", "type"), "test-type")
+
+			_, err := services.DefaultServices(platform.Platform{Root: root}, logger.Logger{})
+			g.Expect(err).To(gomega.MatchError("binding test-binding is declared by both platform bindings and SERVICE_BINDING_ROOT"))
+		})
+
+		it("reads bindings once when SERVICE_BINDING_ROOT is the platform bindings", func() {
+			root := internal.ScratchDir(t, "services")
+			defer internal.ReplaceEnv(t, "SERVICE_BINDING_ROOT", filepath.Join(root, "bindings"))()
+
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "type"), "test-type")
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "password"), "test-password")
+
+			s, err := services.DefaultServices(platform.Platform{Root: root}, logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+
+			g.Expect(s).To(gomega.Equal(services.Services{
+				{
+					BindingName: "test-binding",
+					Credentials: services.Credentials{"password": "test-password"},
+					Label:       "test-type",
+				},
+			}))
+		})
+
+		it("ignores platform bindings without a kind", func() {
+			root := internal.ScratchDir(t, "services")
+			defer internal.ProtectEnv(t, "SERVICE_BINDING_ROOT")()
+			g.Expect(os.Unsetenv("SERVICE_BINDING_ROOT")).To(gomega.Succeed())
+
+			internal.WriteTestFile(t, filepath.Join(root, "bindings", "test-binding", "type"), "test-type")
+
+			s, err := services.DefaultServices(platform.Platform{Root: root}, logger.Logger{})
+			g.Expect(err).To(gomega.Succeed())
+
+			g.Expect(s).To(gomega.BeEmpty())
+		})
 	}, spec.Report(report.Terminal{}))
 }
@@ -0,0 +1,358 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package simulator runs a buildpack's bin/detect and bin/build locally, the way the lifecycle does, so that buildpacks
+// can be integration tested without building images.
+package simulator
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/buildpacks/libbuildpack/v2/buildpack"
+	"github.com/buildpacks/libbuildpack/v2/buildpackplan"
+	"github.com/buildpacks/libbuildpack/v2/buildplan"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/buildpacks/libbuildpack/v2/logger"
+	"github.com/buildpacks/libbuildpack/v2/resolution"
+)
+
+// Simulator runs a buildpack against an application.
+type Simulator struct {
+	// Buildpack is the path to the root of the buildpack, containing buildpack.toml, bin/detect, and bin/build.
+	Buildpack string
+
+	// Application is the path to the application.  The buildpack is run with it as the working directory.
+	Application string
+
+	// Root is the path to a directory in which the platform, layers, and plan files are created.  Required.
+	Root string
+
+	// StackID is the value of CNB_STACK_ID.
+	StackID string
+
+	// PlatformAPI is the value of CNB_PLATFORM_API.  Optional.
+	PlatformAPI string
+
+	// PlatformEnvironment are environment variables written to <platform>/env.  Optional.
+	PlatformEnvironment map[string]string
+
+	// Environment are additional environment variables of the buildpack process.  The process does not inherit the
+	// environment of the simulator other than PATH and HOME.  Optional.
+	Environment map[string]string
+}
+
+// Output is the output of running a buildpack executable.
+type Output struct {
+	// ExitCode is the exit code of the executable.
+	ExitCode int
+
+	// Stdout is the captured standard output.
+	Stdout string
+
+	// Stderr is the captured standard error.
+	Stderr string
+}
+
+// DetectResult is the result of running bin/detect.
+type DetectResult struct {
+	Output
+
+	// Plans are the build plans written by the buildpack.
+	Plans buildplan.Plans
+}
+
+// BuildResult is the result of running bin/build.
+type BuildResult struct {
+	Output
+
+	// Plans is the buildpack plan after the build.  Before buildpack API 0.5 buildpacks may rewrite it.
+	Plans buildpackplan.Plans
+
+	// Layers are the layers written by the buildpack.  Each layer is identified by its metadata file.
+	Layers []layers.Layer
+
+	// LaunchMetadata is the content of launch.toml.
+	LaunchMetadata layers.Metadata
+}
+
+// PlatformRoot returns the path to the platform directory.
+func (s Simulator) PlatformRoot() string {
+	return filepath.Join(s.Root, "platform")
+}
+
+// LayersRoot returns the path to the layers directory.
+func (s Simulator) LayersRoot() string {
+	return filepath.Join(s.Root, "layers")
+}
+
+// Detect runs bin/detect with the platform directory and build plan path as arguments and, from buildpack API 0.8, in
+// CNB_PLATFORM_DIR and CNB_BUILD_PLAN_PATH.
+func (s Simulator) Detect() (DetectResult, error) {
+	s, err := s.absolute()
+	if err != nil {
+		return DetectResult{}, err
+	}
+
+	if err := s.writePlatform(); err != nil {
+		return DetectResult{}, err
+	}
+
+	plan := filepath.Join(s.Root, "detect", "plan.toml")
+	if err := internal.WriteFile(plan, 0644, ""); err != nil {
+		return DetectResult{}, err
+	}
+
+	o, err := s.run("detect", []argument{
+		{s.PlatformRoot(), "CNB_PLATFORM_DIR"},
+		{plan, "CNB_BUILD_PLAN_PATH"},
+	})
+	if err != nil {
+		return DetectResult{}, err
+	}
+
+	r := DetectResult{Output: o}
+	if _, err := toml.DecodeFile(plan, &r.Plans); err != nil {
+		return DetectResult{}, err
+	}
+
+	return r, nil
+}
+
+// Build runs bin/build with the layers directory, platform directory, and buildpack plan path as arguments and, from
+// buildpack API 0.8, in CNB_LAYERS_DIR, CNB_PLATFORM_DIR, and CNB_BP_PLAN_PATH.  The layers directory is emptied before
+// the build.
+func (s Simulator) Build(plans buildpackplan.Plans) (BuildResult, error) {
+	s, err := s.absolute()
+	if err != nil {
+		return BuildResult{}, err
+	}
+
+	if err := s.writePlatform(); err != nil {
+		return BuildResult{}, err
+	}
+
+	if err := os.RemoveAll(s.LayersRoot()); err != nil {
+		return BuildResult{}, err
+	}
+
+	plan := filepath.Join(s.Root, "build", "plan.toml")
+	if err := internal.WriteTomlFile(plan, 0644, plans); err != nil {
+		return BuildResult{}, err
+	}
+
+	if err := os.MkdirAll(s.LayersRoot(), 0755); err != nil {
+		return BuildResult{}, err
+	}
+
+	o, err := s.run("build", []argument{
+		{s.LayersRoot(), "CNB_LAYERS_DIR"},
+		{s.PlatformRoot(), "CNB_PLATFORM_DIR"},
+		{plan, "CNB_BP_PLAN_PATH"},
+	})
+	if err != nil {
+		return BuildResult{}, err
+	}
+
+	r := BuildResult{Output: o}
+	if _, err := toml.DecodeFile(plan, &r.Plans); err != nil {
+		return BuildResult{}, err
+	}
+
+	l := layers.Layers{Root: s.LayersRoot()}
+
+	if r.LaunchMetadata, err = l.ReadApplicationMetadata(); err != nil {
+		return BuildResult{}, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(s.LayersRoot(), "*.toml"))
+	if err != nil {
+		return BuildResult{}, err
+	}
+	sort.Strings(files)
+
+	for _, f := range files {
+		name := strings.TrimSuffix(filepath.Base(f), ".toml")
+		if name == "launch" || name == "build" || name == "store" {
+			continue
+		}
+
+		r.Layers = append(r.Layers, l.Layer(name))
+	}
+
+	return r, nil
+}
+
+// Run runs bin/detect and, if detection passes, resolves the build plan as the lifecycle would for a group containing
+// only this buildpack and runs bin/build with the resulting buildpack plan.  If detection does not pass, the build
+// result is empty.
+func (s Simulator) Run() (DetectResult, BuildResult, error) {
+	d, err := s.Detect()
+	if err != nil {
+		return DetectResult{}, BuildResult{}, err
+	}
+
+	if d.ExitCode != 0 {
+		return d, BuildResult{}, nil
+	}
+
+	bp, err := buildpack.New(s.Buildpack, logger.Logger{})
+	if err != nil {
+		return DetectResult{}, BuildResult{}, err
+	}
+
+	r, err := resolution.Resolve(resolution.Buildpack{ID: bp.Info.ID, Passed: true, Plans: d.Plans})
+	if err != nil {
+		return d, BuildResult{}, fmt.Errorf("unable to resolve build plan: %w", err)
+	}
+
+	b, err := s.Build(r.Plans[bp.Info.ID])
+	if err != nil {
+		return DetectResult{}, BuildResult{}, err
+	}
+
+	return d, b, nil
+}
+
+// absolute returns a copy of the simulator with absolute paths.  The executables are run in the application directory,
+// so relative paths would otherwise be resolved against it.
+func (s Simulator) absolute() (Simulator, error) {
+	var err error
+
+	for _, p := range []*string{&s.Buildpack, &s.Application, &s.Root} {
+		if *p == "" {
+			continue
+		}
+
+		if *p, err = filepath.Abs(*p); err != nil {
+			return Simulator{}, err
+		}
+	}
+
+	return s, nil
+}
+
+// argument is a lifecycle argument, passed positionally and, from buildpack API 0.8, in an environment variable.
+type argument struct {
+	value string
+	name  string
+}
+
+func (s Simulator) run(executable string, arguments []argument) (Output, error) {
+	bp, err := buildpack.New(s.Buildpack, logger.Logger{})
+	if err != nil {
+		return Output{}, err
+	}
+
+	var args []string
+	env := s.environment()
+
+	for _, a := range arguments {
+		args = append(args, a.value)
+
+		if !bp.API.LessThan(buildpack.EnvironmentArgumentsAPI) {
+			env = append(env, fmt.Sprintf("%s=%s", a.name, a.value))
+		}
+	}
+
+	cmd := exec.Command(filepath.Join(s.Buildpack, "bin", executable), args...)
+	cmd.Dir = s.Application
+	cmd.Env = env
+
+	stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+
+	o := Output{}
+
+	if err := cmd.Run(); err != nil {
+		e, ok := err.(*exec.ExitError)
+		if !ok {
+			return Output{}, fmt.Errorf("unable to run %s: %w", executable, err)
+		}
+
+		o.ExitCode = e.ExitCode()
+	}
+
+	o.Stdout, o.Stderr = stdout.String(), stderr.String()
+	return o, nil
+}
+
+func (s Simulator) environment() []string {
+	var env []string
+
+	for _, k := range []string{"PATH", "HOME"} {
+		if v, ok := os.LookupEnv(k); ok {
+			env = append(env, fmt.Sprintf("%s=%s", k, v))
+		}
+	}
+
+	env = append(env,
+		fmt.Sprintf("CNB_STACK_ID=%s", s.StackID),
+		fmt.Sprintf("CNB_BUILDPACK_DIR=%s", s.Buildpack),
+	)
+
+	if s.PlatformAPI != "" {
+		env = append(env, fmt.Sprintf("CNB_PLATFORM_API=%s", s.PlatformAPI))
+	}
+
+	for _, k := range sortedKeys(s.Environment) {
+		env = append(env, fmt.Sprintf("%s=%s", k, s.Environment[k]))
+	}
+
+	return env
+}
+
+func (s Simulator) writePlatform() error {
+	if s.Root == "" {
+		return fmt.Errorf("simulator root must be set")
+	}
+
+	env := filepath.Join(s.PlatformRoot(), "env")
+
+	if err := os.RemoveAll(env); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(env, 0755); err != nil {
+		return err
+	}
+
+	for k, v := range s.PlatformEnvironment {
+		if err := ioutil.WriteFile(filepath.Join(env, k), []byte(v), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sortedKeys(m map[string]string) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
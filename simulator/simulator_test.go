@@ -0,0 +1,241 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package simulator_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/buildpackplan"
+	"github.com/buildpacks/libbuildpack/v2/buildplan"
+	"github.com/buildpacks/libbuildpack/v2/internal"
+	"github.com/buildpacks/libbuildpack/v2/layers"
+	"github.com/buildpacks/libbuildpack/v2/simulator"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestSimulator(t *testing.T) {
+	spec.Run(t, "Simulator", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		var (
+			root string
+			s    simulator.Simulator
+		)
+
+		executable := func(name string, content string) {
+			t.Helper()
+
+			file := filepath.Join(s.Buildpack, "bin", name)
+			internal.WriteTestFile(t, file, content)
+			g.Expect(os.Chmod(file, 0755)).To(gomega.Succeed())
+		}
+
+		it.Before(func() {
+			root = internal.ScratchDir(t, "simulator")
+
+			s = simulator.Simulator{
+				Buildpack:           filepath.Join(root, "buildpack"),
+				Application:         filepath.Join(root, "application"),
+				Root:                filepath.Join(root, "root"),
+				StackID:             "test-stack",
+				PlatformAPI:         "0.4",
+				PlatformEnvironment: map[string]string{"TEST_PLATFORM_KEY": "test-platform-value"},
+				Environment:         map[string]string{"TEST_KEY": "test-value"},
+			}
+
+			internal.WriteTestFile(t, filepath.Join(s.Buildpack, "buildpack.toml"), `[buildpack]
+id = "test-buildpack"
+`)
+			internal.TouchTestFile(t, s.Application, "test-file")
+
+			executable("detect", `#!/bin/sh
+set -e
+echo "stack $CNB_STACK_ID platform-api $CNB_PLATFORM_API key $TEST_KEY"
+echo "platform $(cat "$1/env/TEST_PLATFORM_KEY")"
+echo "error output" >&2
+test -f test-file
+cat > "$2" <<TOML
+[[provides]]
+  name = "test-entry"
+
+[[requires]]
+  name = "test-entry"
+  version = "1.0.0"
+TOML
+`)
+
+			executable("build", `#!/bin/sh
+set -e
+echo "$(cat "$2/env/TEST_PLATFORM_KEY")"
+cp "$3" "$1/plan-copy"
+mkdir -p "$1/test-layer"
+echo "test-content" > "$1/test-layer/test-file"
+cat > "$1/test-layer.toml" <<TOML
+launch = true
+TOML
+cat > "$1/launch.toml" <<TOML
+[[processes]]
+  type = "web"
+  command = "test-command"
+TOML
+`)
+		})
+
+		it("runs detect with the platform and plan arguments", func() {
+			r, err := s.Detect()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.ExitCode).To(gomega.Equal(0))
+			g.Expect(r.Stdout).To(gomega.Equal("stack test-stack platform-api 0.4 key test-value\nplatform test-platform-value\n"))
+			g.Expect(r.Stderr).To(gomega.Equal("error output\n"))
+			g.Expect(r.Plans).To(gomega.Equal(buildplan.Plans{Plan: buildplan.Plan{
+				Provides: []buildplan.Provided{{Name: "test-entry"}},
+				Requires: []buildplan.Required{{Name: "test-entry", Version: "1.0.0"}},
+			}}))
+		})
+
+		it("returns the exit code of detect", func() {
+			executable("detect", `#!/bin/sh
+exit 100
+`)
+
+			r, err := s.Detect()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.ExitCode).To(gomega.Equal(100))
+		})
+
+		it("runs build with the layers, platform, and plan arguments", func() {
+			r, err := s.Build(buildpackplan.Plans{Entries: []buildpackplan.Plan{{Name: "test-entry", Version: "1.0.0"}}})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.ExitCode).To(gomega.Equal(0))
+			g.Expect(r.Stdout).To(gomega.Equal("test-platform-value\n"))
+			g.Expect(r.Plans).To(gomega.Equal(buildpackplan.Plans{Entries: []buildpackplan.Plan{{Name: "test-entry", Version: "1.0.0"}}}))
+			g.Expect(filepath.Join(s.LayersRoot(), "plan-copy")).To(internal.HaveContent(`[[entries]]
+  name = "test-entry"
+  version = "1.0.0"
+`))
+
+			g.Expect(r.Layers).To(gomega.HaveLen(1))
+			g.Expect(r.Layers[0].Root).To(gomega.Equal(filepath.Join(s.LayersRoot(), "test-layer")))
+			g.Expect(filepath.Join(r.Layers[0].Root, "test-file")).To(internal.HaveContent("test-content\n"))
+			g.Expect(r.LaunchMetadata.Processes).To(gomega.Equal(layers.Processes{{Type: "web", Command: "test-command"}}))
+		})
+
+		it("resolves relative paths against the working directory", func() {
+			defer internal.ReplaceWorkingDirectory(t, root)()
+
+			s.Buildpack, s.Application, s.Root = "buildpack", "application", "root"
+
+			r, err := s.Detect()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(r.ExitCode).To(gomega.Equal(0))
+
+			b, err := s.Build(buildpackplan.Plans{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(b.ExitCode).To(gomega.Equal(0))
+			g.Expect(filepath.Join(root, "root", "layers", "test-layer", "test-file")).To(internal.HaveContent("test-content\n"))
+		})
+
+		it("passes arguments in environment variables from buildpack API 0.8", func() {
+			executable("detect", `#!/bin/sh
+echo "${CNB_PLATFORM_DIR:-unset} ${CNB_BUILD_PLAN_PATH:-unset}"
+`)
+			executable("build", `#!/bin/sh
+echo "${CNB_LAYERS_DIR:-unset} ${CNB_PLATFORM_DIR:-unset} ${CNB_BP_PLAN_PATH:-unset}"
+`)
+
+			d, err := s.Detect()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(d.Stdout).To(gomega.Equal("unset unset\n"))
+
+			b, err := s.Build(buildpackplan.Plans{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(b.Stdout).To(gomega.Equal("unset unset unset\n"))
+
+			internal.WriteTestFile(t, filepath.Join(s.Buildpack, "buildpack.toml"), `api = "0.8"
+
+[buildpack]
+id = "test-buildpack"
+`)
+
+			d, err = s.Detect()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(d.Stdout).To(gomega.Equal(fmt.Sprintf("%s %s\n",
+				s.PlatformRoot(), filepath.Join(s.Root, "detect", "plan.toml"))))
+
+			b, err = s.Build(buildpackplan.Plans{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+			g.Expect(b.Stdout).To(gomega.Equal(fmt.Sprintf("%s %s %s\n",
+				s.LayersRoot(), s.PlatformRoot(), filepath.Join(s.Root, "build", "plan.toml"))))
+		})
+
+		it("does not inherit the environment of the simulator", func() {
+			defer internal.ReplaceEnv(t, "BP_OFFLINE", "true")()
+			defer internal.ReplaceEnv(t, "CNB_PLATFORM_API", "0.9")()
+			s.PlatformAPI = ""
+
+			executable("detect", `#!/bin/sh
+echo "offline ${BP_OFFLINE:-unset} platform-api ${CNB_PLATFORM_API:-unset} key $TEST_KEY"
+`)
+
+			r, err := s.Detect()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.Stdout).To(gomega.Equal("offline unset platform-api unset key test-value\n"))
+		})
+
+		it("removes layers from previous builds", func() {
+			internal.WriteTestFile(t, filepath.Join(s.LayersRoot(), "stale-layer.toml"), "")
+
+			r, err := s.Build(buildpackplan.Plans{})
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(r.Layers).To(gomega.HaveLen(1))
+			g.Expect(filepath.Join(s.LayersRoot(), "stale-layer.toml")).NotTo(gomega.BeAnExistingFile())
+		})
+
+		it("runs detect, resolves the build plan, and runs build", func() {
+			d, b, err := s.Run()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(d.ExitCode).To(gomega.Equal(0))
+			g.Expect(b.ExitCode).To(gomega.Equal(0))
+			g.Expect(b.Plans).To(gomega.Equal(buildpackplan.Plans{Entries: []buildpackplan.Plan{{Name: "test-entry", Version: "1.0.0"}}}))
+		})
+
+		it("does not run build when detect fails", func() {
+			executable("detect", `#!/bin/sh
+exit 100
+`)
+
+			d, b, err := s.Run()
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			g.Expect(d.ExitCode).To(gomega.Equal(100))
+			g.Expect(b).To(gomega.BeZero())
+			g.Expect(s.LayersRoot()).NotTo(gomega.BeAnExistingFile())
+		})
+	}, spec.Report(report.Terminal{}))
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var pessimistic = regexp.MustCompile(`~>\s*v?([0-9]+(?:\.[0-9]+)*)((?:[-+][0-9A-Za-z.\-+]*)?)`)
+
+// Constraint is a version constraint.  In addition to exact versions it supports comparisons (>, >=, <, <=, !=),
+// wildcards (1.2.*, 1.x), caret (^1.2) and tilde (~1.2) ranges, the pessimistic operator (~> 1.2), hyphen ranges
+// (1.2 - 1.4), comma-separated conjunctions (>=3.8,<4), and || disjunctions.  An empty constraint matches every
+// version.
+type Constraint struct {
+	raw string
+	c   *semver.Constraints
+}
+
+// Check returns whether a version satisfies the constraint.
+func (c Constraint) Check(version Version) bool {
+	if c.c == nil {
+		return true
+	}
+
+	return c.c.Check(version.semver())
+}
+
+// String makes Constraint satisfy the Stringer interface.  The constraint is printed as it was originally parsed.
+func (c Constraint) String() string {
+	if c.raw == "" {
+		return "*"
+	}
+
+	return c.raw
+}
+
+// NewConstraint parses a version constraint.
+func NewConstraint(constraint string) (Constraint, error) {
+	s := strings.TrimSpace(constraint)
+	if s == "" {
+		return Constraint{}, nil
+	}
+
+	c, err := semver.NewConstraint(expandPessimistic(s))
+	if err != nil {
+		return Constraint{}, fmt.Errorf("invalid version constraint %q: %w", constraint, err)
+	}
+
+	return Constraint{s, c}, nil
+}
+
+// Constraints is a collection of Constraint instances, all of which must be satisfied.
+type Constraints []Constraint
+
+// Check returns whether a version satisfies every constraint.
+func (c Constraints) Check(version Version) bool {
+	for _, constraint := range c {
+		if !constraint.Check(version) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Highest returns the highest of a collection of versions that satisfies every constraint.  Versions that cannot be
+// parsed are ignored.  Returns false if no version satisfies the constraints.
+func (c Constraints) Highest(versions ...string) (Version, bool) {
+	var (
+		highest Version
+		found   bool
+	)
+
+	for _, s := range versions {
+		v, err := NewVersion(s)
+		if err != nil {
+			continue
+		}
+
+		if c.Check(v) && (!found || v.GreaterThan(highest)) {
+			highest, found = v, true
+		}
+	}
+
+	return highest, found
+}
+
+// String makes Constraints satisfy the Stringer interface.
+func (c Constraints) String() string {
+	if len(c) == 0 {
+		return "*"
+	}
+
+	var s []string
+	for _, constraint := range c {
+		s = append(s, constraint.String())
+	}
+
+	return strings.Join(s, " && ")
+}
+
+// NewConstraints parses a collection of version constraints.  Empty constraints are ignored.
+func NewConstraints(constraints ...string) (Constraints, error) {
+	var c Constraints
+
+	for _, s := range constraints {
+		if strings.TrimSpace(s) == "" {
+			continue
+		}
+
+		constraint, err := NewConstraint(s)
+		if err != nil {
+			return nil, err
+		}
+
+		c = append(c, constraint)
+	}
+
+	return c, nil
+}
+
+// expandPessimistic rewrites the pessimistic operator into an explicit range.  ~> 1.2 becomes >= 1.2, < 2 and
+// ~> 1.2.3 becomes >= 1.2.3, < 1.3.
+func expandPessimistic(constraint string) string {
+	return pessimistic.ReplaceAllStringFunc(constraint, func(s string) string {
+		m := pessimistic.FindStringSubmatch(s)
+		segments := strings.Split(m[1], ".")
+
+		if len(segments) > 1 {
+			segments = segments[:len(segments)-1]
+		}
+
+		last, _ := strconv.Atoi(segments[len(segments)-1])
+		segments[len(segments)-1] = strconv.Itoa(last + 1)
+
+		return fmt.Sprintf(">= %s%s, < %s", m[1], m[2], strings.Join(segments, "."))
+	})
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/version"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestConstraint(t *testing.T) {
+	spec.Run(t, "Constraint", func(t *testing.T, when spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		check := func(constraint string, v string) bool {
+			c, err := version.NewConstraint(constraint)
+			g.Expect(err).NotTo(gomega.HaveOccurred())
+
+			return c.Check(version.MustParse(v))
+		}
+
+		it("matches everything with an empty constraint", func() {
+			g.Expect(check("", "1.2.3")).To(gomega.BeTrue())
+		})
+
+		it("matches exact versions", func() {
+			g.Expect(check("1.2.3", "1.2.3")).To(gomega.BeTrue())
+			g.Expect(check("1.2.3", "1.2.4")).To(gomega.BeFalse())
+		})
+
+		it("matches wildcards", func() {
+			g.Expect(check("1.2.*", "1.2.9")).To(gomega.BeTrue())
+			g.Expect(check("1.2.*", "1.3.0")).To(gomega.BeFalse())
+			g.Expect(check("11.x", "11.0.2")).To(gomega.BeTrue())
+		})
+
+		it("matches caret ranges", func() {
+			g.Expect(check("^1.2", "1.9.0")).To(gomega.BeTrue())
+			g.Expect(check("^1.2", "2.0.0")).To(gomega.BeFalse())
+		})
+
+		it("matches tilde ranges", func() {
+			g.Expect(check("~1.2", "1.2.9")).To(gomega.BeTrue())
+			g.Expect(check("~1.2", "1.3.0")).To(gomega.BeFalse())
+		})
+
+		it("matches pessimistic ranges", func() {
+			g.Expect(check("~> 11.0", "11.0.2")).To(gomega.BeTrue())
+			g.Expect(check("~> 11.0", "11.6.0")).To(gomega.BeTrue())
+			g.Expect(check("~> 11.0", "12.0.0")).To(gomega.BeFalse())
+			g.Expect(check("~> 1.2.3", "1.2.9")).To(gomega.BeTrue())
+			g.Expect(check("~> 1.2.3", "1.3.0")).To(gomega.BeFalse())
+			g.Expect(check("~> 1.2.3", "1.2.2")).To(gomega.BeFalse())
+		})
+
+		it("matches conjunctions", func() {
+			g.Expect(check(">=3.8,<4", "3.9.1")).To(gomega.BeTrue())
+			g.Expect(check(">=3.8,<4", "4.0.0")).To(gomega.BeFalse())
+			g.Expect(check(">=3.8,<4", "3.7.0")).To(gomega.BeFalse())
+		})
+
+		it("matches disjunctions", func() {
+			g.Expect(check("1.* || 3.*", "3.1.0")).To(gomega.BeTrue())
+			g.Expect(check("1.* || 3.*", "2.1.0")).To(gomega.BeFalse())
+		})
+
+		it("returns an error for an invalid constraint", func() {
+			_, err := version.NewConstraint("invalid-constraint")
+			g.Expect(err).To(gomega.HaveOccurred())
+		})
+
+		when("constraints", func() {
+
+			it("requires every constraint to match", func() {
+				c, err := version.NewConstraints("11.*", "", ">= 11.0.4")
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				g.Expect(c.Check(version.MustParse("11.0.5"))).To(gomega.BeTrue())
+				g.Expect(c.Check(version.MustParse("11.0.3"))).To(gomega.BeFalse())
+				g.Expect(c.String()).To(gomega.Equal("11.* && >= 11.0.4"))
+			})
+
+			it("returns the highest matching version", func() {
+				c, err := version.NewConstraints("1.*")
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				v, ok := c.Highest("1.0.0", "1.10.0", "1.9.0", "2.0.0", "invalid-version")
+				g.Expect(ok).To(gomega.BeTrue())
+				g.Expect(v.String()).To(gomega.Equal("1.10.0"))
+			})
+
+			it("returns false when no version matches", func() {
+				c, err := version.NewConstraints("3.*")
+				g.Expect(err).NotTo(gomega.HaveOccurred())
+
+				_, ok := c.Highest("1.0.0", "2.0.0")
+				g.Expect(ok).To(gomega.BeFalse())
+			})
+		})
+	}, spec.Report(report.Terminal{}))
+}
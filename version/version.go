@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+var zero = semver.MustParse("0.0.0")
+
+// Version is a semantic version.  Versions with fewer than three components (e.g. 1.2) are coerced to their full form
+// for comparison.
+type Version struct {
+	v *semver.Version
+}
+
+// Compare compares this version to another one.  It returns -1, 0, or 1 if this version is less than, equal to, or
+// greater than the other version.  A zero Version is equal to 0.0.0.
+func (v Version) Compare(o Version) int {
+	return v.semver().Compare(o.semver())
+}
+
+// Equal returns whether this version is equal to another one.
+func (v Version) Equal(o Version) bool {
+	return v.Compare(o) == 0
+}
+
+// GreaterThan returns whether this version is greater than another one.
+func (v Version) GreaterThan(o Version) bool {
+	return v.Compare(o) > 0
+}
+
+// LessThan returns whether this version is less than another one.
+func (v Version) LessThan(o Version) bool {
+	return v.Compare(o) < 0
+}
+
+// String makes Version satisfy the Stringer interface.  The version is printed as it was originally parsed.
+func (v Version) String() string {
+	if v.v == nil {
+		return ""
+	}
+
+	return v.v.Original()
+}
+
+// MarshalText makes Version satisfy the encoding.TextMarshaler interface.
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// UnmarshalText makes Version satisfy the encoding.TextUnmarshaler interface.
+func (v *Version) UnmarshalText(text []byte) error {
+	p, err := NewVersion(string(text))
+	if err != nil {
+		return err
+	}
+
+	*v = p
+	return nil
+}
+
+// IsZero returns whether the version is the zero Version.
+func (v Version) IsZero() bool {
+	return v.v == nil
+}
+
+func (v Version) semver() *semver.Version {
+	if v.v == nil {
+		return zero
+	}
+
+	return v.v
+}
+
+// MustParse parses a version, panicking if the version is invalid.  It is intended for use with constant values.
+func MustParse(version string) Version {
+	v, err := NewVersion(version)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// NewVersion parses a version.
+func NewVersion(version string) (Version, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return Version{}, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	return Version{v}, nil
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright 2018-2020 the original author or authors.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *      https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package version_test
+
+import (
+	"testing"
+
+	"github.com/buildpacks/libbuildpack/v2/version"
+	"github.com/onsi/gomega"
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestVersion(t *testing.T) {
+	spec.Run(t, "Version", func(t *testing.T, _ spec.G, it spec.S) {
+
+		g := gomega.NewWithT(t)
+
+		it("compares versions", func() {
+			g.Expect(version.MustParse("1.10.0").GreaterThan(version.MustParse("1.9.0"))).To(gomega.BeTrue())
+			g.Expect(version.MustParse("0.2").Equal(version.MustParse("0.2.0"))).To(gomega.BeTrue())
+			g.Expect(version.MustParse("0.2").LessThan(version.MustParse("0.10"))).To(gomega.BeTrue())
+		})
+
+		it("treats a zero version as 0.0.0", func() {
+			g.Expect(version.Version{}.Equal(version.MustParse("0.0.0"))).To(gomega.BeTrue())
+		})
+
+		it("prints the original version", func() {
+			g.Expect(version.MustParse("0.2").String()).To(gomega.Equal("0.2"))
+		})
+
+		it("returns an error for an invalid version", func() {
+			_, err := version.NewVersion("invalid-version")
+			g.Expect(err).To(gomega.HaveOccurred())
+		})
+	}, spec.Report(report.Terminal{}))
+}